@@ -1,49 +1,224 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"gopkg.in/go-playground/validator.v9"
+	"gopkg.in/yaml.v2"
+
 	"github.com/jatgam/wishlist-api/utils"
 )
 
+const (
+	defaultSecret     = "A super secret key for jwt auth"
+	defaultDBPassword = "changeme"
+	// MinSecretLength is the shortest accepted AUTH_SECRET, in bytes. HS512
+	// needs a key at least as long as its 512 bit hash.
+	MinSecretLength = 64
+)
+
 type Config struct {
-	DB           *DBConfig
-	Secret       string
-	JWTRealmName string
-	EMail        *SGEmailConfig
+	Server       *ServerConfig       `yaml:"server" validate:"required"`
+	DB           *DBConfig           `yaml:"db" validate:"required"`
+	Secret       string              `yaml:"authSecret" validate:"required"`
+	JWTRealmName string              `yaml:"jwtRealmName" validate:"required"`
+	EMail        *SGEmailConfig      `yaml:"email" validate:"required"`
+	Registration *RegistrationConfig `yaml:"registration" validate:"required"`
+	// DevMode allows the default and short secrets, for running locally.
+	DevMode bool `yaml:"devMode"`
+}
+
+// ServerConfig is where the API listens and how it handles requests. The
+// metrics and health ports listen on every interface.
+type ServerConfig struct {
+	Host        string `yaml:"host" validate:"required"`
+	Port        string `yaml:"port" validate:"required,numeric"`
+	MetricsPort string `yaml:"metricsPort" validate:"required,numeric"`
+	HealthPort  string `yaml:"healthPort" validate:"required,numeric"`
+	// MaxRequests limits how many requests are handled at once, 0 is
+	// unlimited.
+	MaxRequests int    `yaml:"maxRequests" validate:"min=0"`
+	GinMode     string `yaml:"ginMode" validate:"oneof=debug release test"`
+	LogLevel    string `yaml:"logLevel" validate:"oneof=panic fatal error warn warning info debug trace"`
+	// MaxGoroutines fails the liveness check once exceeded, as that many
+	// usually means goroutines are leaking or stuck.
+	MaxGoroutines int `yaml:"maxGoroutines" validate:"min=1"`
+	// DrainDelay is how long the API keeps accepting requests once a
+	// shutdown starts, while the failing readiness check takes it out of
+	// load balancing.
+	DrainDelay time.Duration `yaml:"drainDelay" validate:"min=0"`
+	// ShutdownTimeout is how long in flight requests then get to finish.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" validate:"min=0"`
 }
 
 type SGEmailConfig struct {
-	SendGridAPIKey string
-	FromName       string
-	FromAddress    string
-	Debug          bool
+	SendGridAPIKey string `yaml:"sendGridAPIKey"`
+	FromName       string `yaml:"fromName" validate:"required"`
+	FromAddress    string `yaml:"fromAddress" validate:"required,email"`
+	Debug          bool   `yaml:"debug"`
+	// HealthCheck adds SendGrid to the readiness checks.
+	HealthCheck bool `yaml:"healthCheck"`
 }
 
-type DBConfig struct {
-	Hostname string
-	Database string
-	User     string
-	Password string
+// RegistrationConfig is who can register and how new accounts are verified.
+// Mode is open, invite-only, needing a code from an admin, or closed.
+// Accounts whose email isn't verified within the VerifyWindow are deleted,
+// checking every CleanupInterval, and the verification email can be resent
+// once per ResendCooldown.
+type RegistrationConfig struct {
+	Mode            string        `yaml:"mode" validate:"oneof=open invite-only closed"`
+	VerifyWindow    time.Duration `yaml:"verifyWindow" validate:"min=1"`
+	CleanupInterval time.Duration `yaml:"cleanupInterval" validate:"min=1"`
+	ResendCooldown  time.Duration `yaml:"resendCooldown" validate:"min=0"`
 }
 
-func GetConfig() *Config {
-	var newConf Config
+// DBConfig is the database connection. Driver is mysql, postgres or sqlite3,
+// and for sqlite3 the Database is the path of the database file.
+type DBConfig struct {
+	Driver   string `yaml:"driver" validate:"required,oneof=mysql postgres sqlite3"`
+	Hostname string `yaml:"hostname"`
+	Database string `yaml:"name" validate:"required"`
+	User     string `yaml:"username"`
+	Password string `yaml:"password"`
+	SSLMode  string `yaml:"sslmode" validate:"oneof=disable allow prefer require verify-ca verify-full"`
+}
 
-	newConf = Config{
+// GetConfig builds the configuration from the defaults, then the config file
+// if a path is given, then the environment variables, each overriding the
+// last.
+func GetConfig(path string) (*Config, error) {
+	newConf := &Config{
+		Server: &ServerConfig{
+			Host:          "localhost",
+			Port:          "4000",
+			MetricsPort:   "4001",
+			HealthPort:    "4002",
+			GinMode:       "debug",
+			LogLevel:      "debug",
+			MaxGoroutines: 10000,
+			// Together inside the default 30 second kubernetes grace
+			// period.
+			DrainDelay:      5 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+		},
 		DB: &DBConfig{
-			Hostname: utils.GetEnv("DB_HOSTNAME", "localhost"),
-			Database: utils.GetEnv("DB_NAME", "wishlist"),
-			User:     utils.GetEnv("DB_USERNAME", "wishlist"),
-			Password: utils.GetEnv("DB_PASSWORD", "changeme"),
+			Driver:   "mysql",
+			Hostname: "localhost",
+			Database: "wishlist",
+			User:     "wishlist",
+			Password: defaultDBPassword,
+			SSLMode:  "disable",
 		},
-		Secret:       utils.GetEnv("AUTH_SECRET", "A super secret key for jwt auth"),
-		JWTRealmName: utils.GetEnv("JWT_REALM_NAME", "jatgam-wishlist"),
+		Secret:       defaultSecret,
+		JWTRealmName: "jatgam-wishlist",
 		EMail: &SGEmailConfig{
-			SendGridAPIKey: utils.GetEnv("SENDGRID_API_KEY", ""),
-			FromName:       utils.GetEnv("EMAIL_FROM_NAME", "Wishlist Admin"),
-			FromAddress:    utils.GetEnv("EMAIL_FROM_ADDRESS", "wishlist@example.com"),
-			Debug:          utils.GetEnvAsBool("EMAIL_DEBUG", "false"),
+			FromName:    "Wishlist Admin",
+			FromAddress: "wishlist@example.com",
+		},
+		Registration: &RegistrationConfig{
+			Mode:            "open",
+			VerifyWindow:    72 * time.Hour,
+			CleanupInterval: time.Hour,
+			ResendCooldown:  5 * time.Minute,
 		},
 	}
 
-	return &newConf
+	if path != "" {
+		file, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// Strict, so a misspelled setting is an error instead of being ignored.
+		if err := yaml.UnmarshalStrict(file, newConf); err != nil {
+			return nil, fmt.Errorf("can't parse %s: %s", path, err.Error())
+		}
+	}
+
+	newConf.Server.Host = utils.GetEnv("SERVER_HOST", newConf.Server.Host)
+	newConf.Server.Port = utils.GetEnv("SERVER_PORT", newConf.Server.Port)
+	newConf.Server.MetricsPort = utils.GetEnv("METRICS_PORT", newConf.Server.MetricsPort)
+	newConf.Server.HealthPort = utils.GetEnv("HEALTH_PORT", newConf.Server.HealthPort)
+	maxRequests, err := utils.GetEnvAsInt("MAX_REQUESTS", strconv.Itoa(newConf.Server.MaxRequests))
+	if err != nil {
+		return nil, fmt.Errorf("MAX_REQUESTS is not a number: %s", err.Error())
+	}
+	newConf.Server.MaxRequests = maxRequests
+	maxGoroutines, err := utils.GetEnvAsInt("MAX_GOROUTINES", strconv.Itoa(newConf.Server.MaxGoroutines))
+	if err != nil {
+		return nil, fmt.Errorf("MAX_GOROUTINES is not a number: %s", err.Error())
+	}
+	newConf.Server.MaxGoroutines = maxGoroutines
+	newConf.Server.GinMode = utils.GetEnv("GIN_MODE", newConf.Server.GinMode)
+	newConf.Server.LogLevel = utils.GetEnv("LOG_LEVEL", newConf.Server.LogLevel)
+	drainDelay, err := time.ParseDuration(utils.GetEnv("DRAIN_DELAY", newConf.Server.DrainDelay.String()))
+	if err != nil {
+		return nil, fmt.Errorf("DRAIN_DELAY is not a duration: %s", err.Error())
+	}
+	newConf.Server.DrainDelay = drainDelay
+	shutdownTimeout, err := time.ParseDuration(utils.GetEnv("SHUTDOWN_TIMEOUT", newConf.Server.ShutdownTimeout.String()))
+	if err != nil {
+		return nil, fmt.Errorf("SHUTDOWN_TIMEOUT is not a duration: %s", err.Error())
+	}
+	newConf.Server.ShutdownTimeout = shutdownTimeout
+	newConf.DB.Driver = utils.GetEnv("DB_DRIVER", newConf.DB.Driver)
+	newConf.DB.Hostname = utils.GetEnv("DB_HOSTNAME", newConf.DB.Hostname)
+	newConf.DB.Database = utils.GetEnv("DB_NAME", newConf.DB.Database)
+	newConf.DB.User = utils.GetEnv("DB_USERNAME", newConf.DB.User)
+	newConf.DB.Password = utils.GetEnv("DB_PASSWORD", newConf.DB.Password)
+	newConf.DB.SSLMode = utils.GetEnv("DB_SSLMODE", newConf.DB.SSLMode)
+	newConf.Secret = utils.GetEnv("AUTH_SECRET", newConf.Secret)
+	newConf.JWTRealmName = utils.GetEnv("JWT_REALM_NAME", newConf.JWTRealmName)
+	newConf.EMail.SendGridAPIKey = utils.GetEnv("SENDGRID_API_KEY", newConf.EMail.SendGridAPIKey)
+	newConf.EMail.FromName = utils.GetEnv("EMAIL_FROM_NAME", newConf.EMail.FromName)
+	newConf.EMail.FromAddress = utils.GetEnv("EMAIL_FROM_ADDRESS", newConf.EMail.FromAddress)
+	newConf.EMail.Debug = utils.GetEnvAsBool("EMAIL_DEBUG", strconv.FormatBool(newConf.EMail.Debug))
+	newConf.EMail.HealthCheck = utils.GetEnvAsBool("EMAIL_HEALTH_CHECK", strconv.FormatBool(newConf.EMail.HealthCheck))
+	newConf.Registration.Mode = utils.GetEnv("REGISTRATION_MODE", newConf.Registration.Mode)
+	verifyWindow, err := time.ParseDuration(utils.GetEnv("EMAIL_VERIFY_WINDOW", newConf.Registration.VerifyWindow.String()))
+	if err != nil {
+		return nil, fmt.Errorf("EMAIL_VERIFY_WINDOW is not a duration: %s", err.Error())
+	}
+	newConf.Registration.VerifyWindow = verifyWindow
+	cleanupInterval, err := time.ParseDuration(utils.GetEnv("EMAIL_VERIFY_CLEANUP_INTERVAL", newConf.Registration.CleanupInterval.String()))
+	if err != nil {
+		return nil, fmt.Errorf("EMAIL_VERIFY_CLEANUP_INTERVAL is not a duration: %s", err.Error())
+	}
+	newConf.Registration.CleanupInterval = cleanupInterval
+	resendCooldown, err := time.ParseDuration(utils.GetEnv("EMAIL_VERIFY_RESEND_COOLDOWN", newConf.Registration.ResendCooldown.String()))
+	if err != nil {
+		return nil, fmt.Errorf("EMAIL_VERIFY_RESEND_COOLDOWN is not a duration: %s", err.Error())
+	}
+	newConf.Registration.ResendCooldown = resendCooldown
+	newConf.DevMode = utils.GetEnvAsBool("DEV_MODE", strconv.FormatBool(newConf.DevMode))
+
+	return newConf, nil
+}
+
+// Validate checks every setting has a usable value. Outside dev mode it also
+// refuses secrets left at their defaults, and an AUTH_SECRET too short for
+// HS512.
+func (c *Config) Validate() error {
+	if err := validator.New().Struct(c); err != nil {
+		return err
+	}
+	if c.DB.Driver != "sqlite3" && (c.DB.Hostname == "" || c.DB.User == "") {
+		return fmt.Errorf("DB_HOSTNAME and DB_USERNAME are required for %s", c.DB.Driver)
+	}
+	if c.DevMode {
+		return nil
+	}
+	if c.Secret == defaultSecret {
+		return errors.New("AUTH_SECRET is set to the default, set a random secret or enable DEV_MODE")
+	}
+	if len(c.Secret) < MinSecretLength {
+		return fmt.Errorf("AUTH_SECRET is %d bytes, HS512 needs at least %d", len(c.Secret), MinSecretLength)
+	}
+	if c.DB.Driver != "sqlite3" && c.DB.Password == defaultDBPassword {
+		return errors.New("DB_PASSWORD is set to the default, set a password or enable DEV_MODE")
+	}
+	return nil
 }
@@ -2,28 +2,60 @@ package db
 
 import (
 	"fmt"
+	"net"
 	"os"
 
 	"github.com/jinzhu/gorm"
-	_ "github.com/jinzhu/gorm/dialects/mysql" // import _ for gorm
+	_ "github.com/jinzhu/gorm/dialects/mysql"    // import _ for gorm
+	_ "github.com/jinzhu/gorm/dialects/postgres" // import _ for gorm
+	_ "github.com/jinzhu/gorm/dialects/sqlite"   // import _ for gorm
+	// The sqlite dialect would pull in an older driver, so this version is
+	// required directly.
+	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/jatgam/wishlist-api/config"
 )
 
-var DB *gorm.DB
+// Supported database drivers, named as the gorm dialects.
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+)
 
+// Connect opens the configured database, exiting if it can't.
 func Connect(dbConf *config.DBConfig) *gorm.DB {
-	connectString := fmt.Sprintf("%s:%s@(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", dbConf.User, dbConf.Password, dbConf.Hostname, dbConf.Database)
-	db, err := gorm.Open("mysql", connectString)
+	connectString, err := connectionString(dbConf)
 	if err != nil {
 		fmt.Println("db err: ", err)
 		os.Exit(1)
 	}
-	db.LogMode(true)
-	DB = db
-	return DB
+	db, err := gorm.Open(dbConf.Driver, connectString)
+	if err != nil {
+		fmt.Println("db err: ", err)
+		os.Exit(1)
+	}
+	if dbConf.Driver == DriverSQLite {
+		// SQLite allows a single writer, and an in memory database only
+		// exists for the connection that opened it.
+		db.DB().SetMaxOpenConns(1)
+	}
+	return db
 }
 
-func GetDB() *gorm.DB {
-	return DB
+// connectionString builds the data source name for the configured driver.
+func connectionString(dbConf *config.DBConfig) (string, error) {
+	switch dbConf.Driver {
+	case DriverMySQL:
+		return fmt.Sprintf("%s:%s@(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", dbConf.User, dbConf.Password, dbConf.Hostname, dbConf.Database), nil
+	case DriverPostgres:
+		host, port, err := net.SplitHostPort(dbConf.Hostname)
+		if err != nil {
+			host, port = dbConf.Hostname, "5432"
+		}
+		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", host, port, dbConf.User, dbConf.Password, dbConf.Database, dbConf.SSLMode), nil
+	case DriverSQLite:
+		return dbConf.Database, nil
+	}
+	return "", fmt.Errorf("unsupported database driver %q", dbConf.Driver)
 }
@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"errors"
+	"net/http"
 	"time"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
@@ -19,16 +21,40 @@ type login struct {
 
 var identityKey = "id"
 
+// ErrEMailNotVerified refuses the login of a user that hasn't verified their
+// email address yet.
+var ErrEMailNotVerified = errors.New("email address is not verified")
+
+// revokedKey marks a request whose token was refused by the Authorizator
+// because the password changed after it was issued.
+const revokedKey = "jwt_revoked"
+
+// allowPasswordResetKey marks a route that users who must change their
+// password can still use, and passwordResetKey a request refused because they
+// haven't yet.
+const (
+	allowPasswordResetKey = "jwt_allow_passwordreset"
+	passwordResetKey      = "jwt_passwordreset"
+)
+
+// AllowPasswordReset lets users whose token has passwordreset set use the
+// route, which is otherwise refused until they change their password. It has
+// to run before the jwt middleware.
+func AllowPasswordReset(c *gin.Context) {
+	c.Set(allowPasswordResetKey, true)
+}
+
 // JwtPayload is the data struct to be encoded/decoded from a jwt token.
 type JwtPayload struct {
 	ID            int  `json:"id"`
 	PasswordReset bool `json:"passwordreset"`
 	UserLevel     uint `json:"userlevel"`
+	TokenVersion  int  `json:"tokenversion"`
 }
 
 // CreateJWTMiddleware creates the handlers for JWT authentication for use in
-// Gin routes.
-func CreateJWTMiddleware(jwtSecretKey string, realmName string) *jwt.GinJWTMiddleware {
+// Gin routes. Logins are checked against the users repository.
+func CreateJWTMiddleware(jwtSecretKey string, realmName string, users models.UserRepository) *jwt.GinJWTMiddleware {
 	jwtMiddleware, err := jwt.New(&jwt.GinJWTMiddleware{
 		Realm:            realmName,
 		Key:              []byte(jwtSecretKey),
@@ -42,6 +68,7 @@ func CreateJWTMiddleware(jwtSecretKey string, realmName string) *jwt.GinJWTMiddl
 					identityKey:     v.ID,
 					"passwordreset": v.PasswordReset,
 					"userlevel":     v.UserLevel,
+					"tokenversion":  v.TokenVersion,
 				}
 			}
 			return jwt.MapClaims{}
@@ -50,10 +77,13 @@ func CreateJWTMiddleware(jwtSecretKey string, realmName string) *jwt.GinJWTMiddl
 			mylogger := microservice.GetLogger(c)
 			claims := jwt.ExtractClaims(c)
 			mylogger.Debugf("JWT: Found Claims: %v", claims)
+			// Tokens from before token versions were added are version 0.
+			tokenVersion, _ := claims["tokenversion"].(float64)
 			return &JwtPayload{
 				ID:            int(claims[identityKey].(float64)),
 				PasswordReset: claims["passwordreset"].(bool),
 				UserLevel:     uint(claims["userlevel"].(float64)),
+				TokenVersion:  int(tokenVersion),
 			}
 		},
 		Authenticator: func(c *gin.Context) (interface{}, error) {
@@ -65,30 +95,74 @@ func CreateJWTMiddleware(jwtSecretKey string, realmName string) *jwt.GinJWTMiddl
 			}
 			userID := loginVals.Username
 			password := loginVals.Password
-			foundUser, err := models.FindOneUser(&models.UserModel{Username: userID}, models.UserAuthScope)
+			foundUser, err := users.FindUserByUsername(userID)
 			if err != nil {
 				mylogger.Errorf("JWT: Failed to lookup user: %s", err.Error())
 				metrics.FailedLogin.WithLabelValues(metrics.LoginFailedUser).Inc()
 				return nil, jwt.ErrFailedAuthentication
 			}
+			if foundUser == nil {
+				mylogger.Errorf("JWT: No such user: %s", userID)
+				metrics.FailedLogin.WithLabelValues(metrics.LoginFailedUser).Inc()
+				return nil, jwt.ErrFailedAuthentication
+			}
 			if foundUser.ValidatePassword(password) {
+				if !foundUser.EMailVerified {
+					mylogger.Errorf("JWT: EMail not verified: %s", userID)
+					metrics.FailedLogin.WithLabelValues(metrics.LoginFailedUnverified).Inc()
+					return nil, ErrEMailNotVerified
+				}
 				mylogger.Debugf("JWT: Successful Login: %s", userID)
 				return &JwtPayload{
 					ID:            foundUser.ID,
 					PasswordReset: foundUser.PasswordReset,
 					UserLevel:     foundUser.UserLevel,
+					TokenVersion:  foundUser.TokenVersion,
 				}, nil
 			}
 			mylogger.Errorf("JWT: Login Failed: %s", userID)
 			metrics.FailedLogin.WithLabelValues(metrics.LoginFailedPassword).Inc()
 			return nil, jwt.ErrFailedAuthentication
 		},
+		// Authorizator refuses tokens issued before the users last password
+		// change, and tokens of users that no longer exist. Users that must
+		// change their password are refused everywhere but the routes that
+		// AllowPasswordReset.
 		Authorizator: func(data interface{}, c *gin.Context) bool {
+			mylogger := microservice.GetLogger(c)
+			payload, ok := data.(*JwtPayload)
+			if !ok {
+				return false
+			}
+			foundUser, err := users.FindUser(payload.ID)
+			if err != nil {
+				mylogger.Errorf("JWT: Failed to lookup user: %s", err.Error())
+				return false
+			}
+			if foundUser == nil || foundUser.TokenVersion != payload.TokenVersion {
+				mylogger.Debugf("JWT: Revoked token for user: %v", payload.ID)
+				c.Set(revokedKey, true)
+				return false
+			}
+			if payload.PasswordReset && !c.GetBool(allowPasswordResetKey) {
+				mylogger.Debugf("JWT: Password change required for user: %v", payload.ID)
+				c.Set(passwordResetKey, true)
+				return false
+			}
 			return true
 		},
 		Unauthorized: func(c *gin.Context, code int, message string) {
 			mylogger := microservice.GetLogger(c)
 			mylogger.Error("JWT: Invalid Login")
+			// A revoked token needs a new login, not a permission the user
+			// lacks, so don't let it through as the default 403.
+			if c.GetBool(revokedKey) {
+				code = http.StatusUnauthorized
+				message = "token is revoked, log in again"
+			}
+			if c.GetBool(passwordResetKey) {
+				message = "password change required"
+			}
 			c.JSON(code, gin.H{
 				"code":    code,
 				"message": message,
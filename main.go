@@ -1,42 +1,82 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"github.com/heptiolabs/healthcheck"
+	"github.com/sirupsen/logrus"
 	"gopkg.in/go-playground/validator.v9"
 
 	"github.com/jatgam/wishlist-api/config"
 	"github.com/jatgam/wishlist-api/db"
 	"github.com/jatgam/wishlist-api/jwt"
 	"github.com/jatgam/wishlist-api/microservice"
+	"github.com/jatgam/wishlist-api/migrations"
 	"github.com/jatgam/wishlist-api/models"
 	"github.com/jatgam/wishlist-api/routes"
+	"github.com/jatgam/wishlist-api/service"
 	"github.com/jatgam/wishlist-api/service/sgmail"
+	"github.com/jatgam/wishlist-api/utils"
 	"github.com/jatgam/wishlist-api/validation"
 )
 
-const (
-	port        = "4000"
-	host        = "localhost"
-	metricsPort = "4001"
-	healthPort  = "4002"
-)
-
 func main() {
 	var exposePorts bool
+	var configFile string
 
-	flag.BoolVar(&exposePorts, "expose-ports", false, "Expose Ports outside the docker network")
+	flag.BoolVar(&exposePorts, "expose-ports", false, "Expose Ports outside the docker network, the same as setting SERVER_HOST to 0.0.0.0")
+	flag.StringVar(&configFile, "config", utils.GetEnv("CONFIG_FILE", ""), "YAML config file, overridden by environment variables")
+	flag.Usage = usage
 	flag.Parse()
 
-	serviceConfig := config.GetConfig()
-	db := db.Connect(serviceConfig.DB)
-	db.AutoMigrate(&models.UserModel{}, &models.ItemModel{})
-	defer db.Close()
+	serviceConfig, err := config.GetConfig(configFile)
+	if err != nil {
+		logrus.Fatalf("Can't load config: %s", err.Error())
+	}
+	if err := serviceConfig.Validate(); err != nil {
+		logrus.Fatalf("Invalid config: %s", err.Error())
+	}
+	if exposePorts {
+		serviceConfig.Server.Host = "0.0.0.0"
+	}
+	logLevel, err := logrus.ParseLevel(serviceConfig.Server.LogLevel)
+	if err != nil {
+		logrus.Fatalf("Invalid config: %s", err.Error())
+	}
+	logrus.SetLevel(logLevel)
+	gin.SetMode(serviceConfig.Server.GinMode)
+
+	conn := db.Connect(serviceConfig.DB)
+	defer conn.Close()
+	// Log every query only while debugging.
+	conn.LogMode(logLevel >= logrus.DebugLevel)
+
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(conn, flag.Args()[1:]); err != nil {
+			logrus.Fatalf("Migrate failed: %s", err.Error())
+		}
+		return
+	}
+	if err := migrations.CheckCurrent(conn); err != nil {
+		logrus.Fatalf("Refusing to start: %s", err.Error())
+	}
+
+	sgmail.SetupMail(serviceConfig.EMail.SendGridAPIKey, serviceConfig.EMail.FromName, serviceConfig.EMail.FromAddress, serviceConfig.EMail.Debug)
 
-	router := microservice.NewMicroservice(metricsPort, healthPort, 0, true)
+	router := microservice.NewMicroservice(serviceConfig.Server.MetricsPort, serviceConfig.Server.HealthPort, serviceConfig.Server.MaxRequests, logLevel)
+	router.HealthCheck.AddReadinessCheck("database", healthcheck.DatabasePingCheck(conn.DB(), time.Second))
+	router.HealthCheck.AddLivenessCheck("goroutines", healthcheck.GoroutineCountCheck(serviceConfig.Server.MaxGoroutines))
+	if serviceConfig.EMail.HealthCheck {
+		// Checked in the background, so probes and scrapes don't each call
+		// SendGrid.
+		mailCheck := healthcheck.Timeout(sgmail.GetMailer().Check, 5*time.Second)
+		router.HealthCheck.AddReadinessCheck("mail", healthcheck.Async(mailCheck, time.Minute))
+	}
 	router.StartHealthRouter()
 
 	// Prometheus metrics can grow if a new metric is created for every url.
@@ -47,39 +87,61 @@ func main() {
 			parts := strings.Split(url, "?")
 			url = parts[0]
 		}
+		// Routes like /item/id/:itemID/tag/:tagID have several parameters,
+		// each of them is replaced.
 		for _, p := range c.Params {
-			if p.Key == "itemID" {
-				url = strings.Replace(url, p.Value, ":itemID", 1)
-				break
-			}
-			if p.Key == "pwResetToken" {
-				url = strings.Replace(url, p.Value, ":pwResetToken", 1)
-				break
-			}
-			if p.Key == "rank" {
-				url = strings.Replace(url, p.Value, ":rank", 1)
-				break
-			}
+			url = strings.Replace(url, p.Value, ":"+p.Key, 1)
 		}
 		return url
 	}
 
-	ginjwt := jwt.CreateJWTMiddleware(serviceConfig.Secret, serviceConfig.JWTRealmName)
+	users := models.NewUserRepository(conn)
+	svc := service.NewService(users, models.NewItemRepository(conn), models.NewWishlistRepository(conn), models.NewTagRepository(conn),
+		models.NewInviteRepository(conn), service.Settings{RegistrationMode: serviceConfig.Registration.Mode, Secret: []byte(serviceConfig.Secret),
+			VerifyWindow: serviceConfig.Registration.VerifyWindow, ResendCooldown: serviceConfig.Registration.ResendCooldown})
+	ginjwt := jwt.CreateJWTMiddleware(serviceConfig.Secret, serviceConfig.JWTRealmName, users)
 
 	// Custom Validation
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("passcomplexity", validation.ComplexityValidator)
 		v.RegisterValidation("notblank", validation.NotBlank)
+		v.RegisterValidation("currency", validation.CurrencyValidator)
+		v.RegisterValidation("price", validation.PriceValidator)
 	}
 
-	sgmail.SetupMail(serviceConfig.EMail.SendGridAPIKey, serviceConfig.EMail.FromName, serviceConfig.EMail.FromAddress, serviceConfig.EMail.Debug)
-
-	routes.SetupRoutes(&router.RouterGroup, ginjwt)
+	routes.SetupRoutes(&router.RouterGroup, ginjwt, svc)
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	cleanupDone := make(chan struct{})
+	go func() {
+		defer close(cleanupDone)
+		deleteUnverifiedUsers(cleanupCtx, svc, serviceConfig.Registration.CleanupInterval)
+	}()
 
-	if exposePorts {
-		router.Run("0.0.0.0" + ":" + port)
-	} else {
-		router.Run(host + ":" + port)
+	// Returns once the requests have drained. The cleanup is stopped next,
+	// then the deferred close of the database runs.
+	err = router.ListenAndServe(serviceConfig.Server.Host+":"+serviceConfig.Server.Port,
+		serviceConfig.Server.DrainDelay, serviceConfig.Server.ShutdownTimeout)
+	if err != nil {
+		logrus.Errorf("Shutdown: %s", err.Error())
 	}
+	stopCleanup()
+	<-cleanupDone
+}
 
+// deleteUnverifiedUsers removes the accounts that were never verified, now
+// and then every interval, until the context is cancelled.
+func deleteUnverifiedUsers(ctx context.Context, svc *service.Service, interval time.Duration) {
+	logger := logrus.WithField("task", "deleteUnverifiedUsers")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if deleted, err := svc.DeleteUnverifiedUsers(logger); err == nil && deleted > 0 {
+			logger.Infof("Deleted %v unverified users", deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
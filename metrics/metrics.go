@@ -6,9 +6,12 @@ import (
 )
 
 const (
-	metricLabelItemError  = "item_error"
-	metricLabelLoginError = "login_error"
-	metricLabelUserError  = "user_error"
+	metricLabelItemError   = "item_error"
+	metricLabelLoginError  = "login_error"
+	metricLabelUserError   = "user_error"
+	metricLabelListError   = "wishlist_error"
+	metricLabelTagError    = "tag_error"
+	metricLabelInviteError = "invite_error"
 
 	ItemAddError                   = "item_add_error"
 	ItemAddDataValidationError     = "item_add_validation_error"
@@ -17,13 +20,35 @@ const (
 	ItemGetError                   = "item_get_error"
 	ItemDeleteError                = "item_delete_error"
 	ItemDeleteValidationError      = "item_delete_validation_error"
+	ListAddError                   = "wishlist_add_error"
+	ListDataValidationError        = "wishlist_validation_error"
+	ListEditError                  = "wishlist_edit_error"
+	ListGetError                   = "wishlist_get_error"
+	ListDeleteError                = "wishlist_delete_error"
+	TagAddError                    = "tag_add_error"
+	TagDataValidationError         = "tag_validation_error"
+	TagEditError                   = "tag_edit_error"
+	TagGetError                    = "tag_get_error"
+	TagDeleteError                 = "tag_delete_error"
+	InviteAddError                 = "invite_add_error"
+	InviteDataValidationError      = "invite_validation_error"
+	InviteGetError                 = "invite_get_error"
+	InviteDeleteError              = "invite_delete_error"
 	LoginFailedUser                = "login_invalid_user"
 	LoginFailedPassword            = "login_invalid_password"
+	LoginFailedUnverified          = "login_unverified_email"
 	RequestDataValidationError     = "data_validation_error"
 	UserRegFailedError             = "user_reg_error"
 	UserPasswordForgotError        = "user_pwforgot_error"
 	UserPasswordResetValidateError = "user_pwresetvalidate_error"
 	UserPasswordResetError         = "user_pwreset_error"
+	UserGetProfileError            = "user_getprofile_error"
+	UserEditProfileError           = "user_editprofile_error"
+	UserEMailConfirmError          = "user_emailconfirm_error"
+	UserChangePasswordError        = "user_changepassword_error"
+	UserRequirePasswordError       = "user_requirepassword_error"
+	UserVerifyEMailError           = "user_verifyemail_error"
+	UserVerifyEMailResendError     = "user_verifyemailresend_error"
 )
 
 var (
@@ -49,4 +74,22 @@ var (
 		Help: "Errors encountered when dealing with items",
 	},
 		[]string{metricLabelItemError})
+
+	WishlistErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wishlist_api_wishlist_errors",
+		Help: "Errors encountered when dealing with wishlists",
+	},
+		[]string{metricLabelListError})
+
+	TagErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wishlist_api_tag_errors",
+		Help: "Errors encountered when dealing with tags",
+	},
+		[]string{metricLabelTagError})
+
+	InviteErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "wishlist_api_invite_errors",
+		Help: "Errors encountered when dealing with invites",
+	},
+		[]string{metricLabelInviteError})
 )
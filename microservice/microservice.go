@@ -2,51 +2,161 @@ package microservice
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	limit "github.com/aviddiviner/gin-limit"
 	"github.com/gin-gonic/gin"
 	"github.com/heptiolabs/healthcheck"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	ginprometheus "github.com/zsais/go-gin-prometheus"
 )
 
 type Microservice struct {
 	*gin.Engine
+	// HealthCheck publishes the state of each check it is given as a
+	// wishlist_healthcheck_status gauge on the metrics port.
 	HealthCheck   healthcheck.Handler
 	Prometheus    *ginprometheus.Prometheus
 	MetricsRouter *gin.Engine
 	MetricsPort   string
 	HealthPort    string
+	// Server is the API listener, set by ListenAndServe.
+	Server        *http.Server
+	MetricsServer *http.Server
+	HealthServer  *http.Server
+	// draining is set once shutdown starts, failing the readiness check.
+	draining int32
 }
 
-func NewMicroservice(metricsPort string, healthPort string, requestLimit int, debug bool) *Microservice {
+// NewMicroservice creates the router. A requestLimit of 0 leaves the number of
+// requests handled at once unlimited, and logLevel is the level of the
+// request logs.
+func NewMicroservice(metricsPort string, healthPort string, requestLimit int, logLevel logrus.Level) *Microservice {
 	router := gin.New()
 	metricsRouter := gin.New()
 
-	microService := Microservice{router, healthcheck.NewHandler(), ginprometheus.NewPrometheus("gin_metrics"), metricsRouter, metricsPort, healthPort}
+	microService := &Microservice{
+		Engine:        router,
+		HealthCheck:   healthcheck.NewMetricsHandler(prometheus.DefaultRegisterer, "wishlist"),
+		Prometheus:    ginprometheus.NewPrometheus("gin_metrics"),
+		MetricsRouter: metricsRouter,
+		MetricsPort:   metricsPort,
+		HealthPort:    healthPort,
+	}
 	if requestLimit != 0 {
 		router.Use(limit.MaxAllowed(requestLimit))
 	}
-	microService.Use(initAggregatedLogging())
+	microService.Use(initAggregatedLogging(logLevel))
 	microService.Use(gin.Recovery())
 	logrus.SetFormatter(&logrus.JSONFormatter{})
 
+	// The metrics are served by MetricsServer rather than the listener
+	// ginprometheus would start, which can't be shut down.
 	microService.MetricsRouter.Use(gin.Recovery())
-	microService.Prometheus.SetListenAddressWithRouter(fmt.Sprintf(":%s", microService.MetricsPort), metricsRouter)
-	microService.Prometheus.Use(router)
+	microService.MetricsRouter.GET(microService.Prometheus.MetricsPath, gin.WrapH(promhttp.Handler()))
+	microService.Use(microService.Prometheus.HandlerFunc())
+	microService.MetricsServer = &http.Server{Addr: ":" + metricsPort, Handler: metricsRouter}
+
+	microService.HealthCheck.AddReadinessCheck("shutdown", func() error {
+		if atomic.LoadInt32(&microService.draining) != 0 {
+			return errors.New("shutting down")
+		}
+		return nil
+	})
+	microService.HealthServer = &http.Server{Addr: ":" + healthPort, Handler: fullHealthDetail(microService.HealthCheck)}
 
-	return &microService
+	return microService
 }
 
+// StartHealthRouter serves the health checks in the background, so they are
+// up before the API is ready.
 func (ms *Microservice) StartHealthRouter() {
-	go http.ListenAndServe(":"+ms.HealthPort, ms.HealthCheck)
+	go serve(ms.HealthServer, nil)
+}
+
+// ListenAndServe serves the API on addr, and the metrics, until either fails
+// or SIGINT or SIGTERM is received. It then shuts down, see Shutdown.
+func (ms *Microservice) ListenAndServe(addr string, drainDelay, drainTimeout time.Duration) error {
+	ms.Server = &http.Server{Addr: addr, Handler: ms.Engine}
+	errs := make(chan error, 2)
+	go serve(ms.Server, errs)
+	go serve(ms.MetricsServer, errs)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	var err error
+	select {
+	case err = <-errs:
+		logrus.Errorf("%s, shutting down", err.Error())
+	case sig := <-stop:
+		logrus.Infof("Received %s, shutting down", sig)
+	}
+	if shutdownErr := ms.Shutdown(drainDelay, drainTimeout); err == nil {
+		err = shutdownErr
+	}
+	return err
+}
+
+// Shutdown fails the readiness check, and keeps serving for the delay so load
+// balancers see it and stop sending requests. It then stops the API and
+// metrics listeners once their open requests finish or the timeout passes, and
+// the health listener last.
+func (ms *Microservice) Shutdown(delay, timeout time.Duration) error {
+	atomic.StoreInt32(&ms.draining, 1)
+	time.Sleep(delay)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var err error
+	for _, server := range []*http.Server{ms.Server, ms.MetricsServer, ms.HealthServer} {
+		if server == nil {
+			continue
+		}
+		if shutdownErr := server.Shutdown(ctx); shutdownErr != nil && err == nil {
+			err = shutdownErr
+		}
+	}
+	return err
+}
+
+// fullHealthDetail makes the health endpoints always answer with the result of
+// each check as JSON, which they otherwise only do when asked with ?full=1.
+func fullHealthDetail(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		query.Set("full", "1")
+		r.URL.RawQuery = query.Encode()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serve runs the server until it fails or is shut down, sending any failure to
+// errs. Without errs the failure is only logged.
+func serve(server *http.Server, errs chan<- error) {
+	err := server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return
+	}
+	if errs == nil {
+		logrus.Errorf("Listening on %s failed: %s", server.Addr, err.Error())
+		return
+	}
+	errs <- fmt.Errorf("listening on %s failed: %s", server.Addr, err.Error())
 }
 
 func GetLogger(c *gin.Context) *logrus.Entry {
@@ -64,14 +174,14 @@ func GetLogger(c *gin.Context) *logrus.Entry {
 	return logger
 }
 
-func initAggregatedLogging() gin.HandlerFunc {
+func initAggregatedLogging(logLevel logrus.Level) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		aggLogBuffer := newAggregateLogBuffer()
 		reqLogger := &logrus.Logger{
 			Out:       &aggLogBuffer,
 			Formatter: new(logrus.JSONFormatter),
 			Hooks:     make(logrus.LevelHooks),
-			Level:     logrus.DebugLevel,
+			Level:     logLevel,
 		}
 		start := time.Now()
 		path := c.Request.URL.Path
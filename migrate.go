@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+
+	"github.com/jatgam/wishlist-api/migrations"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up | migrate down [steps] | migrate status")
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [migrate up | migrate down [steps] | migrate status]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Without a command the API is served. The database schema must be up to date.")
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// runMigrate handles the migrate command. Down rolls back one migration unless
+// a number of steps is given.
+func runMigrate(db *gorm.DB, args []string) error {
+	logger := logrus.WithField("command", "migrate")
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(db, logger)
+		logger.Infof("Applied %v migrations", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return errMigrateUsage
+			}
+		}
+		rolledBack, err := migrations.Down(db, steps, logger)
+		logger.Infof("Rolled back %v migrations", rolledBack)
+		return err
+	case "status":
+		statuses, err := migrations.GetStatus(db)
+		if err != nil {
+			return err
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(writer, "%v\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return writer.Flush()
+	default:
+		return errMigrateUsage
+	}
+}
@@ -0,0 +1,100 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// initialSchema is the schema from before migrations were versioned. On mysql
+// the tables are only created if missing, so existing databases adopt it as is.
+var initialSchema = Migration{
+	Version: 1,
+	Name:    "initial",
+	Up: Statements{
+		db.DriverMySQL: {
+			"CREATE TABLE IF NOT EXISTS `users1` (" +
+				"`id` integer UNIQUE AUTO_INCREMENT, " +
+				"`createdAt` DATETIME NOT NULL, " +
+				"`updatedAt` DATETIME NOT NULL, " +
+				"`username` varchar(255) UNIQUE NOT NULL, " +
+				"`hash` varchar(255) NOT NULL, " +
+				"`passwordreset` tinyint(1) NOT NULL DEFAULT false, " +
+				"`passwordResetToken` varchar(255) DEFAULT NULL, " +
+				"`passwordResetExpires` DATETIME DEFAULT NULL, " +
+				"`userlevel` tinyint unsigned NOT NULL, " +
+				"`email` varchar(255) NOT NULL, " +
+				"`firstname` varchar(255) NOT NULL, " +
+				"`lastname` varchar(255) NOT NULL, " +
+				"PRIMARY KEY (`id`))",
+			"CREATE TABLE IF NOT EXISTS `items1` (" +
+				"`id` integer UNIQUE AUTO_INCREMENT, " +
+				"`createdAt` DATETIME NOT NULL, " +
+				"`updatedAt` DATETIME NOT NULL, " +
+				"`name` varchar(255) NOT NULL, " +
+				"`url` varchar(255) NOT NULL, " +
+				"`reserved` tinyint(1) NOT NULL DEFAULT false, " +
+				"`reserverid` integer DEFAULT NULL, " +
+				"`rank` integer DEFAULT NULL, " +
+				"PRIMARY KEY (`id`))",
+		},
+		db.DriverPostgres: {
+			`CREATE TABLE "users1" (` +
+				`"id" serial PRIMARY KEY, ` +
+				`"createdAt" timestamp with time zone NOT NULL, ` +
+				`"updatedAt" timestamp with time zone NOT NULL, ` +
+				`"username" varchar(255) UNIQUE NOT NULL, ` +
+				`"hash" varchar(255) NOT NULL, ` +
+				`"passwordreset" boolean NOT NULL DEFAULT false, ` +
+				`"passwordResetToken" varchar(255) DEFAULT NULL, ` +
+				`"passwordResetExpires" timestamp with time zone DEFAULT NULL, ` +
+				`"userlevel" smallint NOT NULL, ` +
+				`"email" varchar(255) NOT NULL, ` +
+				`"firstname" varchar(255) NOT NULL, ` +
+				`"lastname" varchar(255) NOT NULL)`,
+			`CREATE TABLE "items1" (` +
+				`"id" serial PRIMARY KEY, ` +
+				`"createdAt" timestamp with time zone NOT NULL, ` +
+				`"updatedAt" timestamp with time zone NOT NULL, ` +
+				`"name" varchar(255) NOT NULL, ` +
+				`"url" varchar(255) NOT NULL, ` +
+				`"reserved" boolean NOT NULL DEFAULT false, ` +
+				`"reserverid" integer DEFAULT NULL, ` +
+				`"rank" integer DEFAULT NULL)`,
+		},
+		db.DriverSQLite: {
+			`CREATE TABLE "users1" (` +
+				`"id" integer PRIMARY KEY AUTOINCREMENT, ` +
+				`"createdAt" datetime NOT NULL, ` +
+				`"updatedAt" datetime NOT NULL, ` +
+				`"username" varchar(255) UNIQUE NOT NULL, ` +
+				`"hash" varchar(255) NOT NULL, ` +
+				`"passwordreset" boolean NOT NULL DEFAULT false, ` +
+				`"passwordResetToken" varchar(255) DEFAULT NULL, ` +
+				`"passwordResetExpires" datetime DEFAULT NULL, ` +
+				`"userlevel" smallint NOT NULL, ` +
+				`"email" varchar(255) NOT NULL, ` +
+				`"firstname" varchar(255) NOT NULL, ` +
+				`"lastname" varchar(255) NOT NULL)`,
+			`CREATE TABLE "items1" (` +
+				`"id" integer PRIMARY KEY AUTOINCREMENT, ` +
+				`"createdAt" datetime NOT NULL, ` +
+				`"updatedAt" datetime NOT NULL, ` +
+				`"name" varchar(255) NOT NULL, ` +
+				`"url" varchar(255) NOT NULL, ` +
+				`"reserved" boolean NOT NULL DEFAULT false, ` +
+				`"reserverid" integer DEFAULT NULL, ` +
+				`"rank" integer DEFAULT NULL)`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"DROP TABLE `items1`",
+			"DROP TABLE `users1`",
+		},
+		db.DriverPostgres: {
+			`DROP TABLE "items1"`,
+			`DROP TABLE "users1"`,
+		},
+		db.DriverSQLite: {
+			`DROP TABLE "items1"`,
+			`DROP TABLE "users1"`,
+		},
+	},
+}
@@ -0,0 +1,74 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// wishlists adds named wishlists, and the owner and wishlist of items.
+var wishlists = Migration{
+	Version: 2,
+	Name:    "wishlists",
+	Up: Statements{
+		db.DriverMySQL: {
+			"CREATE TABLE `wishlists1` (" +
+				"`id` integer UNIQUE AUTO_INCREMENT, " +
+				"`createdAt` DATETIME NOT NULL, " +
+				"`updatedAt` DATETIME NOT NULL, " +
+				"`ownerid` integer NOT NULL, " +
+				"`title` varchar(255) NOT NULL, " +
+				"`description` text, " +
+				"`occasion` varchar(255), " +
+				"`occasionDate` DATETIME DEFAULT NULL, " +
+				"`visibility` varchar(16) NOT NULL DEFAULT 'public', " +
+				"`surprisemode` tinyint(1) NOT NULL DEFAULT false, " +
+				"PRIMARY KEY (`id`))",
+			"ALTER TABLE `items1` " +
+				"ADD COLUMN `ownerid` integer DEFAULT NULL, " +
+				"ADD COLUMN `wishlistid` integer DEFAULT NULL",
+		},
+		db.DriverPostgres: {
+			`CREATE TABLE "wishlists1" (` +
+				`"id" serial PRIMARY KEY, ` +
+				`"createdAt" timestamp with time zone NOT NULL, ` +
+				`"updatedAt" timestamp with time zone NOT NULL, ` +
+				`"ownerid" integer NOT NULL, ` +
+				`"title" varchar(255) NOT NULL, ` +
+				`"description" text, ` +
+				`"occasion" varchar(255), ` +
+				`"occasionDate" timestamp with time zone DEFAULT NULL, ` +
+				`"visibility" varchar(16) NOT NULL DEFAULT 'public', ` +
+				`"surprisemode" boolean NOT NULL DEFAULT false)`,
+			`ALTER TABLE "items1" ` +
+				`ADD COLUMN "ownerid" integer DEFAULT NULL, ` +
+				`ADD COLUMN "wishlistid" integer DEFAULT NULL`,
+		},
+		db.DriverSQLite: {
+			`CREATE TABLE "wishlists1" (` +
+				`"id" integer PRIMARY KEY AUTOINCREMENT, ` +
+				`"createdAt" datetime NOT NULL, ` +
+				`"updatedAt" datetime NOT NULL, ` +
+				`"ownerid" integer NOT NULL, ` +
+				`"title" varchar(255) NOT NULL, ` +
+				`"description" text, ` +
+				`"occasion" varchar(255), ` +
+				`"occasionDate" datetime DEFAULT NULL, ` +
+				`"visibility" varchar(16) NOT NULL DEFAULT 'public', ` +
+				`"surprisemode" boolean NOT NULL DEFAULT false)`,
+			`ALTER TABLE "items1" ADD COLUMN "ownerid" integer DEFAULT NULL`,
+			`ALTER TABLE "items1" ADD COLUMN "wishlistid" integer DEFAULT NULL`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `items1` DROP COLUMN `wishlistid`, DROP COLUMN `ownerid`",
+			"DROP TABLE `wishlists1`",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "items1" DROP COLUMN "wishlistid", DROP COLUMN "ownerid"`,
+			`DROP TABLE "wishlists1"`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "items1" DROP COLUMN "wishlistid"`,
+			`ALTER TABLE "items1" DROP COLUMN "ownerid"`,
+			`DROP TABLE "wishlists1"`,
+		},
+	},
+}
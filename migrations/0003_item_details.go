@@ -0,0 +1,61 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// itemDetails adds the descriptive fields of items.
+var itemDetails = Migration{
+	Version: 3,
+	Name:    "item_details",
+	Up: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `items1` " +
+				"ADD COLUMN `price` decimal(10,2) DEFAULT NULL, " +
+				"ADD COLUMN `currency` varchar(3), " +
+				"ADD COLUMN `quantity` integer NOT NULL DEFAULT 1, " +
+				"ADD COLUMN `notes` text, " +
+				"ADD COLUMN `imageurl` varchar(255), " +
+				"ADD COLUMN `size` varchar(64), " +
+				"ADD COLUMN `color` varchar(64)",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "items1" ` +
+				`ADD COLUMN "price" decimal(10,2) DEFAULT NULL, ` +
+				`ADD COLUMN "currency" varchar(3), ` +
+				`ADD COLUMN "quantity" integer NOT NULL DEFAULT 1, ` +
+				`ADD COLUMN "notes" text, ` +
+				`ADD COLUMN "imageurl" varchar(255), ` +
+				`ADD COLUMN "size" varchar(64), ` +
+				`ADD COLUMN "color" varchar(64)`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "items1" ADD COLUMN "price" decimal(10,2) DEFAULT NULL`,
+			`ALTER TABLE "items1" ADD COLUMN "currency" varchar(3)`,
+			`ALTER TABLE "items1" ADD COLUMN "quantity" integer NOT NULL DEFAULT 1`,
+			`ALTER TABLE "items1" ADD COLUMN "notes" text`,
+			`ALTER TABLE "items1" ADD COLUMN "imageurl" varchar(255)`,
+			`ALTER TABLE "items1" ADD COLUMN "size" varchar(64)`,
+			`ALTER TABLE "items1" ADD COLUMN "color" varchar(64)`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `items1` " +
+				"DROP COLUMN `color`, DROP COLUMN `size`, DROP COLUMN `imageurl`, DROP COLUMN `notes`, " +
+				"DROP COLUMN `quantity`, DROP COLUMN `currency`, DROP COLUMN `price`",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "items1" ` +
+				`DROP COLUMN "color", DROP COLUMN "size", DROP COLUMN "imageurl", DROP COLUMN "notes", ` +
+				`DROP COLUMN "quantity", DROP COLUMN "currency", DROP COLUMN "price"`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "items1" DROP COLUMN "color"`,
+			`ALTER TABLE "items1" DROP COLUMN "size"`,
+			`ALTER TABLE "items1" DROP COLUMN "imageurl"`,
+			`ALTER TABLE "items1" DROP COLUMN "notes"`,
+			`ALTER TABLE "items1" DROP COLUMN "quantity"`,
+			`ALTER TABLE "items1" DROP COLUMN "currency"`,
+			`ALTER TABLE "items1" DROP COLUMN "price"`,
+		},
+	},
+}
@@ -0,0 +1,82 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// reservations moves the single reserver of an item into the reservations
+// table, which allows several users to each reserve part of the quantity.
+// Rolling back keeps only one reserver per item.
+var reservations = Migration{
+	Version: 4,
+	Name:    "reservations",
+	Up: Statements{
+		db.DriverMySQL: {
+			"CREATE TABLE `reservations1` (" +
+				"`id` integer UNIQUE AUTO_INCREMENT, " +
+				"`createdAt` DATETIME NOT NULL, " +
+				"`updatedAt` DATETIME NOT NULL, " +
+				"`itemid` integer NOT NULL, " +
+				"`userid` integer NOT NULL, " +
+				"`quantity` integer NOT NULL, " +
+				"PRIMARY KEY (`id`), " +
+				"UNIQUE KEY `idx_reservation_item_user` (`itemid`, `userid`))",
+			"ALTER TABLE `items1` ADD COLUMN `reservedqty` integer NOT NULL DEFAULT 0",
+			"INSERT INTO `reservations1` (`itemid`, `userid`, `quantity`, `createdAt`, `updatedAt`) " +
+				"SELECT `id`, `reserverid`, `quantity`, `updatedAt`, `updatedAt` FROM `items1` WHERE `reserverid` IS NOT NULL",
+			"UPDATE `items1` SET `reservedqty` = `quantity` WHERE `reserverid` IS NOT NULL",
+			"ALTER TABLE `items1` DROP COLUMN `reserverid`",
+		},
+		db.DriverPostgres: {
+			`CREATE TABLE "reservations1" (` +
+				`"id" serial PRIMARY KEY, ` +
+				`"createdAt" timestamp with time zone NOT NULL, ` +
+				`"updatedAt" timestamp with time zone NOT NULL, ` +
+				`"itemid" integer NOT NULL, ` +
+				`"userid" integer NOT NULL, ` +
+				`"quantity" integer NOT NULL, ` +
+				`CONSTRAINT "idx_reservation_item_user" UNIQUE ("itemid", "userid"))`,
+			`ALTER TABLE "items1" ADD COLUMN "reservedqty" integer NOT NULL DEFAULT 0`,
+			`INSERT INTO "reservations1" ("itemid", "userid", "quantity", "createdAt", "updatedAt") ` +
+				`SELECT "id", "reserverid", "quantity", "updatedAt", "updatedAt" FROM "items1" WHERE "reserverid" IS NOT NULL`,
+			`UPDATE "items1" SET "reservedqty" = "quantity" WHERE "reserverid" IS NOT NULL`,
+			`ALTER TABLE "items1" DROP COLUMN "reserverid"`,
+		},
+		db.DriverSQLite: {
+			`CREATE TABLE "reservations1" (` +
+				`"id" integer PRIMARY KEY AUTOINCREMENT, ` +
+				`"createdAt" datetime NOT NULL, ` +
+				`"updatedAt" datetime NOT NULL, ` +
+				`"itemid" integer NOT NULL, ` +
+				`"userid" integer NOT NULL, ` +
+				`"quantity" integer NOT NULL, ` +
+				`CONSTRAINT "idx_reservation_item_user" UNIQUE ("itemid", "userid"))`,
+			`ALTER TABLE "items1" ADD COLUMN "reservedqty" integer NOT NULL DEFAULT 0`,
+			`INSERT INTO "reservations1" ("itemid", "userid", "quantity", "createdAt", "updatedAt") ` +
+				`SELECT "id", "reserverid", "quantity", "updatedAt", "updatedAt" FROM "items1" WHERE "reserverid" IS NOT NULL`,
+			`UPDATE "items1" SET "reservedqty" = "quantity" WHERE "reserverid" IS NOT NULL`,
+			`ALTER TABLE "items1" DROP COLUMN "reserverid"`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `items1` ADD COLUMN `reserverid` integer DEFAULT NULL",
+			"UPDATE `items1` SET `reserverid` = " +
+				"(SELECT MIN(`userid`) FROM `reservations1` WHERE `reservations1`.`itemid` = `items1`.`id`)",
+			"ALTER TABLE `items1` DROP COLUMN `reservedqty`",
+			"DROP TABLE `reservations1`",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "items1" ADD COLUMN "reserverid" integer DEFAULT NULL`,
+			`UPDATE "items1" SET "reserverid" = ` +
+				`(SELECT MIN("userid") FROM "reservations1" WHERE "reservations1"."itemid" = "items1"."id")`,
+			`ALTER TABLE "items1" DROP COLUMN "reservedqty"`,
+			`DROP TABLE "reservations1"`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "items1" ADD COLUMN "reserverid" integer DEFAULT NULL`,
+			`UPDATE "items1" SET "reserverid" = ` +
+				`(SELECT MIN("userid") FROM "reservations1" WHERE "reservations1"."itemid" = "items1"."id")`,
+			`ALTER TABLE "items1" DROP COLUMN "reservedqty"`,
+			`DROP TABLE "reservations1"`,
+		},
+	},
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// itemStatus adds the item status, marking items that were already fully
+// reserved as reserved.
+var itemStatus = Migration{
+	Version: 5,
+	Name:    "item_status",
+	Up: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `items1` ADD COLUMN `status` varchar(16) NOT NULL DEFAULT 'wanted'",
+			"UPDATE `items1` SET `status` = 'reserved' WHERE `reserved` = true",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "items1" ADD COLUMN "status" varchar(16) NOT NULL DEFAULT 'wanted'`,
+			`UPDATE "items1" SET "status" = 'reserved' WHERE "reserved" = true`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "items1" ADD COLUMN "status" varchar(16) NOT NULL DEFAULT 'wanted'`,
+			`UPDATE "items1" SET "status" = 'reserved' WHERE "reserved" = true`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `items1` DROP COLUMN `status`",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "items1" DROP COLUMN "status"`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "items1" DROP COLUMN "status"`,
+		},
+	},
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// itemSoftDelete lets deleted items be kept for the users that reserved them.
+var itemSoftDelete = Migration{
+	Version: 6,
+	Name:    "item_soft_delete",
+	Up: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `items1` ADD COLUMN `deletedAt` DATETIME DEFAULT NULL",
+			"CREATE INDEX `idx_items1_deletedAt` ON `items1` (`deletedAt`)",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "items1" ADD COLUMN "deletedAt" timestamp with time zone DEFAULT NULL`,
+			`CREATE INDEX "idx_items1_deletedAt" ON "items1" ("deletedAt")`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "items1" ADD COLUMN "deletedAt" datetime DEFAULT NULL`,
+			`CREATE INDEX "idx_items1_deletedAt" ON "items1" ("deletedAt")`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"DELETE FROM `items1` WHERE `deletedAt` IS NOT NULL",
+			"DROP INDEX `idx_items1_deletedAt` ON `items1`",
+			"ALTER TABLE `items1` DROP COLUMN `deletedAt`",
+		},
+		db.DriverPostgres: {
+			`DELETE FROM "items1" WHERE "deletedAt" IS NOT NULL`,
+			`DROP INDEX "idx_items1_deletedAt"`,
+			`ALTER TABLE "items1" DROP COLUMN "deletedAt"`,
+		},
+		db.DriverSQLite: {
+			`DELETE FROM "items1" WHERE "deletedAt" IS NOT NULL`,
+			`DROP INDEX "idx_items1_deletedAt"`,
+			`ALTER TABLE "items1" DROP COLUMN "deletedAt"`,
+		},
+	},
+}
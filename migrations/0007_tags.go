@@ -0,0 +1,65 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// tags adds the users item tags and the table joining them to items.
+var tags = Migration{
+	Version: 7,
+	Name:    "tags",
+	Up: Statements{
+		db.DriverMySQL: {
+			"CREATE TABLE `tags1` (" +
+				"`id` integer UNIQUE AUTO_INCREMENT, " +
+				"`createdAt` DATETIME NOT NULL, " +
+				"`updatedAt` DATETIME NOT NULL, " +
+				"`ownerid` integer NOT NULL, " +
+				"`name` varchar(64) NOT NULL, " +
+				"PRIMARY KEY (`id`), " +
+				"UNIQUE KEY `idx_tag_owner_name` (`ownerid`, `name`))",
+			"CREATE TABLE `itemtags1` (" +
+				"`itemid` integer NOT NULL, " +
+				"`tagid` integer NOT NULL, " +
+				"PRIMARY KEY (`itemid`, `tagid`))",
+		},
+		db.DriverPostgres: {
+			`CREATE TABLE "tags1" (` +
+				`"id" serial PRIMARY KEY, ` +
+				`"createdAt" timestamp with time zone NOT NULL, ` +
+				`"updatedAt" timestamp with time zone NOT NULL, ` +
+				`"ownerid" integer NOT NULL, ` +
+				`"name" varchar(64) NOT NULL, ` +
+				`CONSTRAINT "idx_tag_owner_name" UNIQUE ("ownerid", "name"))`,
+			`CREATE TABLE "itemtags1" (` +
+				`"itemid" integer NOT NULL, ` +
+				`"tagid" integer NOT NULL, ` +
+				`PRIMARY KEY ("itemid", "tagid"))`,
+		},
+		db.DriverSQLite: {
+			`CREATE TABLE "tags1" (` +
+				`"id" integer PRIMARY KEY AUTOINCREMENT, ` +
+				`"createdAt" datetime NOT NULL, ` +
+				`"updatedAt" datetime NOT NULL, ` +
+				`"ownerid" integer NOT NULL, ` +
+				`"name" varchar(64) NOT NULL, ` +
+				`CONSTRAINT "idx_tag_owner_name" UNIQUE ("ownerid", "name"))`,
+			`CREATE TABLE "itemtags1" (` +
+				`"itemid" integer NOT NULL, ` +
+				`"tagid" integer NOT NULL, ` +
+				`PRIMARY KEY ("itemid", "tagid"))`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"DROP TABLE `itemtags1`",
+			"DROP TABLE `tags1`",
+		},
+		db.DriverPostgres: {
+			`DROP TABLE "itemtags1"`,
+			`DROP TABLE "tags1"`,
+		},
+		db.DriverSQLite: {
+			`DROP TABLE "itemtags1"`,
+			`DROP TABLE "tags1"`,
+		},
+	},
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// emailChange holds a users new email address until it is confirmed.
+var emailChange = Migration{
+	Version: 8,
+	Name:    "email_change",
+	Up: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `users1` ADD COLUMN `pendingEmail` varchar(255) DEFAULT NULL",
+			"ALTER TABLE `users1` ADD COLUMN `emailToken` varchar(255) DEFAULT NULL",
+			"ALTER TABLE `users1` ADD COLUMN `emailTokenExpires` DATETIME DEFAULT NULL",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "users1" ADD COLUMN "pendingEmail" varchar(255) DEFAULT NULL`,
+			`ALTER TABLE "users1" ADD COLUMN "emailToken" varchar(255) DEFAULT NULL`,
+			`ALTER TABLE "users1" ADD COLUMN "emailTokenExpires" timestamp with time zone DEFAULT NULL`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "users1" ADD COLUMN "pendingEmail" varchar(255) DEFAULT NULL`,
+			`ALTER TABLE "users1" ADD COLUMN "emailToken" varchar(255) DEFAULT NULL`,
+			`ALTER TABLE "users1" ADD COLUMN "emailTokenExpires" datetime DEFAULT NULL`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `users1` DROP COLUMN `emailTokenExpires`",
+			"ALTER TABLE `users1` DROP COLUMN `emailToken`",
+			"ALTER TABLE `users1` DROP COLUMN `pendingEmail`",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "users1" DROP COLUMN "emailTokenExpires"`,
+			`ALTER TABLE "users1" DROP COLUMN "emailToken"`,
+			`ALTER TABLE "users1" DROP COLUMN "pendingEmail"`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "users1" DROP COLUMN "emailTokenExpires"`,
+			`ALTER TABLE "users1" DROP COLUMN "emailToken"`,
+			`ALTER TABLE "users1" DROP COLUMN "pendingEmail"`,
+		},
+	},
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// tokenVersion counts a users password changes, so tokens issued before the
+// latest change can be refused.
+var tokenVersion = Migration{
+	Version: 9,
+	Name:    "token_version",
+	Up: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `users1` ADD COLUMN `tokenVersion` integer NOT NULL DEFAULT 0",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "users1" ADD COLUMN "tokenVersion" integer NOT NULL DEFAULT 0`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "users1" ADD COLUMN "tokenVersion" integer NOT NULL DEFAULT 0`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `users1` DROP COLUMN `tokenVersion`",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "users1" DROP COLUMN "tokenVersion"`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "users1" DROP COLUMN "tokenVersion"`,
+		},
+	},
+}
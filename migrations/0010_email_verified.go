@@ -0,0 +1,42 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// emailVerified tracks whether a user has proven they own their email
+// address. Accounts from before verification was required are marked
+// verified.
+var emailVerified = Migration{
+	Version: 10,
+	Name:    "email_verified",
+	Up: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `users1` ADD COLUMN `emailVerified` tinyint(1) NOT NULL DEFAULT false",
+			"ALTER TABLE `users1` ADD COLUMN `emailVerifySentAt` DATETIME DEFAULT NULL",
+			"UPDATE `users1` SET `emailVerified` = true",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "users1" ADD COLUMN "emailVerified" boolean NOT NULL DEFAULT false`,
+			`ALTER TABLE "users1" ADD COLUMN "emailVerifySentAt" timestamp with time zone DEFAULT NULL`,
+			`UPDATE "users1" SET "emailVerified" = true`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "users1" ADD COLUMN "emailVerified" boolean NOT NULL DEFAULT false`,
+			`ALTER TABLE "users1" ADD COLUMN "emailVerifySentAt" datetime DEFAULT NULL`,
+			`UPDATE "users1" SET "emailVerified" = true`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `users1` DROP COLUMN `emailVerifySentAt`",
+			"ALTER TABLE `users1` DROP COLUMN `emailVerified`",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "users1" DROP COLUMN "emailVerifySentAt"`,
+			`ALTER TABLE "users1" DROP COLUMN "emailVerified"`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "users1" DROP COLUMN "emailVerifySentAt"`,
+			`ALTER TABLE "users1" DROP COLUMN "emailVerified"`,
+		},
+	},
+}
@@ -0,0 +1,60 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// invites adds the codes that let people register while registration is
+// invite only.
+var invites = Migration{
+	Version: 11,
+	Name:    "invites",
+	Up: Statements{
+		db.DriverMySQL: {
+			"CREATE TABLE `invites1` (" +
+				"`id` integer UNIQUE AUTO_INCREMENT, " +
+				"`createdAt` DATETIME NOT NULL, " +
+				"`updatedAt` DATETIME NOT NULL, " +
+				"`code` varchar(64) NOT NULL, " +
+				"`creatorid` integer NOT NULL, " +
+				"`maxuses` integer NOT NULL, " +
+				"`uses` integer NOT NULL DEFAULT 0, " +
+				"`expiresAt` DATETIME DEFAULT NULL, " +
+				"PRIMARY KEY (`id`), " +
+				"UNIQUE KEY `idx_invite_code` (`code`))",
+		},
+		db.DriverPostgres: {
+			`CREATE TABLE "invites1" (` +
+				`"id" serial PRIMARY KEY, ` +
+				`"createdAt" timestamp with time zone NOT NULL, ` +
+				`"updatedAt" timestamp with time zone NOT NULL, ` +
+				`"code" varchar(64) NOT NULL, ` +
+				`"creatorid" integer NOT NULL, ` +
+				`"maxuses" integer NOT NULL, ` +
+				`"uses" integer NOT NULL DEFAULT 0, ` +
+				`"expiresAt" timestamp with time zone DEFAULT NULL, ` +
+				`CONSTRAINT "idx_invite_code" UNIQUE ("code"))`,
+		},
+		db.DriverSQLite: {
+			`CREATE TABLE "invites1" (` +
+				`"id" integer PRIMARY KEY AUTOINCREMENT, ` +
+				`"createdAt" datetime NOT NULL, ` +
+				`"updatedAt" datetime NOT NULL, ` +
+				`"code" varchar(64) NOT NULL, ` +
+				`"creatorid" integer NOT NULL, ` +
+				`"maxuses" integer NOT NULL, ` +
+				`"uses" integer NOT NULL DEFAULT 0, ` +
+				`"expiresAt" datetime DEFAULT NULL, ` +
+				`CONSTRAINT "idx_invite_code" UNIQUE ("code"))`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"DROP TABLE `invites1`",
+		},
+		db.DriverPostgres: {
+			`DROP TABLE "invites1"`,
+		},
+		db.DriverSQLite: {
+			`DROP TABLE "invites1"`,
+		},
+	},
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import "github.com/jatgam/wishlist-api/db"
+
+// dropEMailToken removes the stored email change tokens, which are now signed
+// like the verification tokens. Changes pending when it runs have to be
+// requested again.
+var dropEMailToken = Migration{
+	Version: 12,
+	Name:    "drop_email_token",
+	Up: Statements{
+		db.DriverMySQL: {
+			"UPDATE `users1` SET `pendingEmail` = NULL",
+			"ALTER TABLE `users1` DROP COLUMN `emailTokenExpires`",
+			"ALTER TABLE `users1` DROP COLUMN `emailToken`",
+		},
+		db.DriverPostgres: {
+			`UPDATE "users1" SET "pendingEmail" = NULL`,
+			`ALTER TABLE "users1" DROP COLUMN "emailTokenExpires"`,
+			`ALTER TABLE "users1" DROP COLUMN "emailToken"`,
+		},
+		db.DriverSQLite: {
+			`UPDATE "users1" SET "pendingEmail" = NULL`,
+			`ALTER TABLE "users1" DROP COLUMN "emailTokenExpires"`,
+			`ALTER TABLE "users1" DROP COLUMN "emailToken"`,
+		},
+	},
+	Down: Statements{
+		db.DriverMySQL: {
+			"ALTER TABLE `users1` ADD COLUMN `emailToken` varchar(255) DEFAULT NULL",
+			"ALTER TABLE `users1` ADD COLUMN `emailTokenExpires` DATETIME DEFAULT NULL",
+			"UPDATE `users1` SET `pendingEmail` = NULL",
+		},
+		db.DriverPostgres: {
+			`ALTER TABLE "users1" ADD COLUMN "emailToken" varchar(255) DEFAULT NULL`,
+			`ALTER TABLE "users1" ADD COLUMN "emailTokenExpires" timestamp with time zone DEFAULT NULL`,
+			`UPDATE "users1" SET "pendingEmail" = NULL`,
+		},
+		db.DriverSQLite: {
+			`ALTER TABLE "users1" ADD COLUMN "emailToken" varchar(255) DEFAULT NULL`,
+			`ALTER TABLE "users1" ADD COLUMN "emailTokenExpires" datetime DEFAULT NULL`,
+			`UPDATE "users1" SET "pendingEmail" = NULL`,
+		},
+	},
+}
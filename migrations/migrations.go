@@ -0,0 +1,175 @@
+// Package migrations versions the database schema. Each migration has SQL
+// statements to apply it and to roll it back for every supported dialect.
+// Applied versions are recorded in the schema_migrations table.
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+)
+
+const tableName = "schema_migrations"
+
+// Migration is a single versioned change to the schema. Statements are run one
+// at a time, since the mysql driver doesn't allow several in one Exec.
+type Migration struct {
+	Version int
+	Name    string
+	Up      Statements
+	Down    Statements
+}
+
+// Statements are the SQL statements of a migration keyed by the dialect they
+// are written for.
+type Statements map[string][]string
+
+// Status is a migration and when it was applied, nil if it is pending.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// ErrSchemaBehind is returned by CheckCurrent when migrations are pending.
+var ErrSchemaBehind = errors.New("the database schema is behind, run the migrate up command")
+
+// all migrations, in the order they are applied. Versions must increase.
+var all = []Migration{
+	initialSchema,
+	wishlists,
+	itemDetails,
+	reservations,
+	itemStatus,
+	itemSoftDelete,
+	tags,
+	emailChange,
+	tokenVersion,
+	emailVerified,
+	invites,
+	dropEMailToken,
+}
+
+type schemaMigration struct {
+	Version   int       `gorm:"column:version;type:integer;primary_key;auto_increment:false"`
+	Name      string    `gorm:"column:name;type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"column:appliedAt;not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return tableName
+}
+
+// Up applies every pending migration in order and returns how many were
+// applied.
+func Up(db *gorm.DB, logger *logrus.Entry) (int, error) {
+	statuses, err := GetStatus(db)
+	if err != nil {
+		return 0, err
+	}
+	applied := 0
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			continue
+		}
+		logger.Infof("Applying migration %v %s", status.Version, status.Name)
+		record := schemaMigration{Version: status.Version, Name: status.Name, AppliedAt: time.Now()}
+		err := run(db, status.Up, func(tx *gorm.DB) error {
+			return tx.Create(&record).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("migration %v %s failed: %s", status.Version, status.Name, err.Error())
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// Down rolls back the latest applied migrations, up to steps of them, and
+// returns how many were rolled back.
+func Down(db *gorm.DB, steps int, logger *logrus.Entry) (int, error) {
+	statuses, err := GetStatus(db)
+	if err != nil {
+		return 0, err
+	}
+	rolledBack := 0
+	for i := len(statuses) - 1; i >= 0 && rolledBack < steps; i-- {
+		status := statuses[i]
+		if status.AppliedAt == nil {
+			continue
+		}
+		logger.Infof("Rolling back migration %v %s", status.Version, status.Name)
+		err := run(db, status.Down, func(tx *gorm.DB) error {
+			return tx.Delete(schemaMigration{}, "version = ?", status.Version).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("rollback of %v %s failed: %s", status.Version, status.Name, err.Error())
+		}
+		rolledBack++
+	}
+	return rolledBack, nil
+}
+
+// GetStatus returns every known migration and when it was applied.
+func GetStatus(db *gorm.DB) ([]Status, error) {
+	if !db.HasTable(&schemaMigration{}) {
+		if err := db.CreateTable(&schemaMigration{}).Error; err != nil {
+			return nil, err
+		}
+	}
+	var records []schemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	appliedAt := map[int]time.Time{}
+	for _, record := range records {
+		appliedAt[record.Version] = record.AppliedAt
+	}
+	statuses := []Status{}
+	for _, migration := range all {
+		status := Status{Migration: migration}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// CheckCurrent returns ErrSchemaBehind if any migration hasn't been applied.
+func CheckCurrent(db *gorm.DB) error {
+	statuses, err := GetStatus(db)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			return ErrSchemaBehind
+		}
+	}
+	return nil
+}
+
+// run executes the statements for the database dialect and then record in a
+// transaction. Note mysql commits DDL statements implicitly, so a failed
+// migration may be partly applied there.
+func run(db *gorm.DB, statements Statements, record func(*gorm.DB) error) error {
+	dialect := db.Dialect().GetName()
+	if _, ok := statements[dialect]; !ok {
+		return fmt.Errorf("no statements for the %s dialect", dialect)
+	}
+	tx := db.Begin()
+	for _, statement := range statements[dialect] {
+		if err := tx.Exec(statement).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
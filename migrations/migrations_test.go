@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jatgam/wishlist-api/config"
+	"github.com/jatgam/wishlist-api/db"
+)
+
+func TestEveryDialect(t *testing.T) {
+	for _, migration := range all {
+		for _, dialect := range []string{db.DriverMySQL, db.DriverPostgres, db.DriverSQLite} {
+			if len(migration.Up[dialect]) == 0 || len(migration.Down[dialect]) == 0 {
+				t.Errorf("Migration %v %s is missing %s statements", migration.Version, migration.Name, dialect)
+			}
+		}
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	entry := logrus.NewEntry(logger)
+	conn := db.Connect(&config.DBConfig{Driver: db.DriverSQLite, Database: ":memory:"})
+	defer conn.Close()
+
+	if err := CheckCurrent(conn); err != ErrSchemaBehind {
+		t.Errorf("CheckCurrent of an empty database returned %v, want %v", err, ErrSchemaBehind)
+	}
+	if applied, err := Up(conn, entry); err != nil || applied != len(all) {
+		t.Fatalf("Up applied %v with error %v, want %v", applied, err, len(all))
+	}
+	if err := CheckCurrent(conn); err != nil {
+		t.Errorf("CheckCurrent after Up returned %v", err)
+	}
+	if applied, err := Up(conn, entry); err != nil || applied != 0 {
+		t.Errorf("A second Up applied %v with error %v, want 0", applied, err)
+	}
+
+	if rolledBack, err := Down(conn, len(all), entry); err != nil || rolledBack != len(all) {
+		t.Fatalf("Down rolled back %v with error %v, want %v", rolledBack, err, len(all))
+	}
+	if conn.HasTable("users1") {
+		t.Error("users1 is left after rolling back every migration")
+	}
+	if applied, err := Up(conn, entry); err != nil || applied != len(all) {
+		t.Errorf("Up after Down applied %v with error %v, want %v", applied, err, len(all))
+	}
+}
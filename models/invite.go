@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// InviteModel is the db structure for a code that lets people register while
+// registration is invite only. A code can be used MaxUses times, until it
+// expires if ExpiresAt is set.
+type InviteModel struct {
+	DefaultModel
+	Code      string     `gorm:"column:code;type:varchar(64);unique;not null"`
+	CreatorID int        `gorm:"column:creatorid;type:integer;not null"`
+	MaxUses   int        `gorm:"column:maxuses;type:integer;not null"`
+	Uses      int        `gorm:"column:uses;type:integer;not null;DEFAULT:0"`
+	ExpiresAt *time.Time `gorm:"column:expiresAt;DEFAULT:NULL"`
+}
+
+func (InviteModel) TableName() string {
+	return "invites1"
+}
+
+type inviteRepository struct {
+	db *gorm.DB
+}
+
+// NewInviteRepository creates an InviteRepository stored in the database.
+func NewInviteRepository(db *gorm.DB) InviteRepository {
+	return &inviteRepository{db: db}
+}
+
+func (r *inviteRepository) FindInvite(inviteID int) (*InviteModel, error) {
+	var model InviteModel
+	err := r.db.Where(map[string]interface{}{"id": inviteID}).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &model, err
+}
+
+// GetInvites returns every invite, the newest first.
+func (r *inviteRepository) GetInvites() (*[]InviteModel, error) {
+	var model []InviteModel
+	err := r.db.Order("id DESC").Find(&model).Error
+	return &model, err
+}
+
+func (r *inviteRepository) CreateInvite(newInvite *InviteModel) error {
+	err := r.db.Create(newInvite).Error
+	return err
+}
+
+func (r *inviteRepository) DeleteInvite(invite *InviteModel) error {
+	err := r.db.Delete(invite).Error
+	return err
+}
+
+// UseInvite counts a use of the code. The use only counts if the code has
+// uses left and hasn't expired at the time given, returns false otherwise.
+func (r *inviteRepository) UseInvite(code string, now time.Time) (bool, error) {
+	condition := "code = ? AND uses < maxuses AND (" + quoted(r.db, "expiresAt") + " IS NULL OR " + quoted(r.db, "expiresAt") + " > ?)"
+	update := r.db.Model(&InviteModel{}).Where(condition, code, now).
+		Updates(map[string]interface{}{"uses": gorm.Expr("uses + 1")})
+	return update.RowsAffected > 0, update.Error
+}
+
+func (r *inviteRepository) ReleaseInvite(code string) error {
+	err := r.db.Model(&InviteModel{}).Where("code = ? AND uses > 0", code).
+		Updates(map[string]interface{}{"uses": gorm.Expr("uses - 1")}).Error
+	return err
+}
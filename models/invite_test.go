@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUseInvite(t *testing.T) {
+	conn := newTestDB(t)
+	defer conn.Close()
+	invites := NewInviteRepository(conn)
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+
+	for _, invite := range []*InviteModel{
+		{Code: "twice", CreatorID: 1, MaxUses: 2},
+		{Code: "expired", CreatorID: 1, MaxUses: 2, ExpiresAt: &expired},
+	} {
+		if err := invites.CreateInvite(invite); err != nil {
+			t.Fatalf("Creating invite %s failed: %s", invite.Code, err.Error())
+		}
+	}
+
+	for i, test := range []struct {
+		code string
+		want bool
+	}{
+		{"twice", true},
+		{"twice", true},
+		{"twice", false},
+		{"expired", false},
+		{"unknown", false},
+	} {
+		if used, err := invites.UseInvite(test.code, now); err != nil || used != test.want {
+			t.Errorf("Use %v of %s returned %v, %v, want %v", i+1, test.code, used, err, test.want)
+		}
+	}
+	if found, _ := invites.GetInvites(); len(*found) != 2 || (*found)[1].Uses != 2 || (*found)[0].Uses != 0 {
+		t.Errorf("GetInvites returned %v, want expired unused and twice used twice", found)
+	}
+
+	if err := invites.ReleaseInvite("twice"); err != nil {
+		t.Fatalf("ReleaseInvite failed: %s", err.Error())
+	}
+	if used, err := invites.UseInvite("twice", now); err != nil || !used {
+		t.Errorf("Using a released invite returned %v, %v, want true", used, err)
+	}
+	for _, code := range []string{"expired", "unknown"} {
+		if err := invites.ReleaseInvite(code); err != nil {
+			t.Errorf("Releasing %s failed: %s", code, err.Error())
+		}
+	}
+	if found, _ := invites.GetInvites(); (*found)[0].Uses != 0 {
+		t.Errorf("Releasing an unused invite left it with %v uses, want 0", (*found)[0].Uses)
+	}
+}
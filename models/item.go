@@ -1,19 +1,47 @@
 package models
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/jinzhu/gorm"
+)
 
-	"github.com/jatgam/wishlist-api/db"
+// Item statuses. An item is wanted until its full quantity is reserved, a
+// reserver can then mark it purchased, and the owner marks it received once
+// they have it.
+const (
+	ItemStatusWanted    = "wanted"
+	ItemStatusReserved  = "reserved"
+	ItemStatusPurchased = "purchased"
+	ItemStatusReceived  = "received"
+	ItemStatusArchived  = "archived"
 )
 
 type ItemModel struct {
 	DefaultModel
-	Name       string    `gorm:"column:name;type:varchar(255);not null"`
-	URL        string    `gorm:"column:url;type:varchar(255);not null"`
-	Reserved   bool      `gorm:"column:reserved;type:tinyint(1);not null;DEFAULT:false"`
-	Reserver   UserModel `gorm:"foreignkey:ReserverID"`
-	ReserverID *int      `gorm:"column:reserverid;type:integer;DEFAULT:NULL"`
-	Rank       int       `gorm:"colunm:rank;type:integer;DEFAULT:NULL"`
+	Owner   UserModel `gorm:"foreignkey:OwnerID"`
+	OwnerID *int      `gorm:"column:ownerid;type:integer;DEFAULT:NULL"`
+	Name    string    `gorm:"column:name;type:varchar(255);not null"`
+	URL     string    `gorm:"column:url;type:varchar(255);not null"`
+	// Reserved is true once the full quantity of the item has been reserved.
+	Reserved         bool               `gorm:"column:reserved;type:boolean;not null;DEFAULT:false"`
+	ReservedQuantity int                `gorm:"column:reservedqty;type:integer;not null;DEFAULT:0"`
+	Reservations     []ReservationModel `gorm:"foreignkey:ItemID"`
+	Status           string             `gorm:"column:status;type:varchar(16);not null;DEFAULT:'wanted'"`
+	Rank             int                `gorm:"column:rank;type:integer;DEFAULT:NULL"`
+	WishlistID       *int               `gorm:"column:wishlistid;type:integer;DEFAULT:NULL"`
+	Price            *float64           `gorm:"column:price;type:decimal(10,2);DEFAULT:NULL"`
+	Currency         string             `gorm:"column:currency;type:varchar(3)"`
+	Quantity         int                `gorm:"column:quantity;type:integer;not null;DEFAULT:1"`
+	Notes            string             `gorm:"column:notes;type:text"`
+	ImageURL         string             `gorm:"column:imageurl;type:varchar(255)"`
+	Size             string             `gorm:"column:size;type:varchar(64)"`
+	Color            string             `gorm:"column:color;type:varchar(64)"`
+	Tags             []TagModel         `gorm:"many2many:itemtags1;jointable_foreignkey:itemid;association_jointable_foreignkey:tagid"`
+	// DeletedAt makes deletes soft, so reservers can still see removed items.
+	DeletedAt *time.Time `gorm:"column:deletedAt;DEFAULT:NULL;index"`
 }
 
 func (ItemModel) TableName() string {
@@ -21,76 +49,246 @@ func (ItemModel) TableName() string {
 }
 
 func ItemDefaultScope(db *gorm.DB) *gorm.DB {
-	return db.Select("id, name, url, reserved, rank, createdAt, updatedAt")
+	return db.Select(quoted(db, "id", "ownerid", "name", "url", "reserved", "reservedqty", "status", "rank", "wishlistid", "price",
+		"currency", "quantity", "notes", "imageurl", "size", "color", "createdAt", "updatedAt", "deletedAt"))
 }
 
-func ItemReserveScope(db *gorm.DB) *gorm.DB {
-	return db.Select("id, name, url, reserved, reserverid, rank, createdAt, updatedAt")
+// ItemUnscopedScope includes soft deleted items.
+func ItemUnscopedScope(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
 }
 
 func ItemOrderScope(db *gorm.DB) *gorm.DB {
-	return db.Order("rank ASC, id DESC")
+	return db.Order(quoted(db, "rank") + " ASC, id DESC")
+}
+
+type itemRepository struct {
+	db *gorm.DB
 }
 
-func GetItems(condition interface{}, scopes ...func(*gorm.DB) *gorm.DB) (*[]ItemModel, error) {
-	db := db.GetDB()
+// NewItemRepository creates an ItemRepository stored in the database.
+func NewItemRepository(db *gorm.DB) ItemRepository {
+	return &itemRepository{db: db}
+}
+
+func (r *itemRepository) FindItem(itemID int, withDeleted bool) (*ItemModel, error) {
+	scopes := []func(*gorm.DB) *gorm.DB{ItemDefaultScope}
+	if withDeleted {
+		scopes = append(scopes, ItemUnscopedScope)
+	}
+	return r.findOneItem(map[string]interface{}{"id": itemID}, scopes...)
+}
+
+func (r *itemRepository) getItems(condition interface{}, scopes ...func(*gorm.DB) *gorm.DB) (*[]ItemModel, error) {
 	if len(scopes) < 1 {
 		scopes = append(scopes, ItemDefaultScope)
 	}
 	var model []ItemModel
-	err := db.Scopes(scopes...).Where(condition).Find(&model).Error
+	err := r.db.Scopes(scopes...).Where(condition).Find(&model).Error
 	if gorm.IsRecordNotFoundError(err) {
 		return nil, nil
 	}
 	return &model, err
 }
 
-func FindOneItem(condition interface{}, scopes ...func(*gorm.DB) *gorm.DB) (*ItemModel, error) {
-	db := db.GetDB()
+func (r *itemRepository) findOneItem(condition interface{}, scopes ...func(*gorm.DB) *gorm.DB) (*ItemModel, error) {
 	if len(scopes) < 1 {
 		scopes = append(scopes, ItemDefaultScope)
 	}
 	var model ItemModel
-	err := db.Scopes(scopes...).Where(condition).First(&model).Error
+	err := r.db.Scopes(scopes...).Where(condition).First(&model).Error
 	if gorm.IsRecordNotFoundError(err) {
 		return nil, nil
 	}
 	return &model, err
 }
 
-func GetWantedItems() (*[]ItemModel, error) {
-	condition := map[string]interface{}{"reserved": false}
-	items, err := GetItems(condition, ItemDefaultScope, ItemOrderScope)
-	return items, err
+// ItemListOptions filters, sorts and pages an item listing. Zero values leave
+// the listing unfiltered, in rank order and unpaged.
+type ItemListOptions struct {
+	Status   string
+	MinPrice *float64
+	MaxPrice *float64
+	Name     string
+	Tag      string
+	Sort     string
+	Desc     bool
+	Limit    int
+	Offset   int
+}
+
+// Item listing sort keys.
+const (
+	ItemSortRank    = "rank"
+	ItemSortCreated = "created"
+	ItemSortPrice   = "price"
+)
+
+var itemSortColumns = map[string]string{
+	ItemSortRank:    "rank",
+	ItemSortCreated: "createdAt",
+	ItemSortPrice:   "price",
+}
+
+// GetWantedItems returns the unreserved items that are not part of a named
+// wishlist, along with the total number matching the options.
+func (r *itemRepository) GetWantedItems(opts ItemListOptions) (*[]ItemModel, int, error) {
+	condition := map[string]interface{}{"reserved": false, "status": ItemStatusWanted, "wishlistid": nil}
+	return r.getItemsPage(condition, opts, ItemDefaultScope)
 }
 
-func GetAllItems() (*[]ItemModel, error) {
+func (r *itemRepository) GetAllItems(opts ItemListOptions) (*[]ItemModel, int, error) {
 	condition := map[string]interface{}{}
-	items, err := GetItems(condition, ItemDefaultScope, ItemOrderScope)
-	return items, err
+	return r.getItemsPage(condition, opts, ItemDefaultScope)
 }
 
-func GetReservedItems(userID int) (*[]ItemModel, error) {
-	condition := map[string]interface{}{"reserverid": userID}
-	items, err := GetItems(condition, ItemDefaultScope, ItemOrderScope)
-	return items, err
+// GetReservedItems returns the items the user holds a reservation for,
+// including items the owner has since deleted.
+func (r *itemRepository) GetReservedItems(userID int, opts ItemListOptions) (*[]ItemModel, int, error) {
+	return r.getItemsPage(map[string]interface{}{}, opts, ItemDefaultScope, ItemUnscopedScope, reservedByScope(userID))
 }
 
-func AddItem(name, url string, rank int) error {
-	db := db.GetDB()
-	newItem := &ItemModel{Name: name, URL: url, Rank: rank}
-	err := db.Create(newItem).Error
+// getItemsPage counts the items matching the condition and options, then
+// loads the requested page of them.
+func (r *itemRepository) getItemsPage(condition interface{}, opts ItemListOptions, scopes ...func(*gorm.DB) *gorm.DB) (*[]ItemModel, int, error) {
+	scopes = append(scopes, itemFilterScope(opts))
+	var total int
+	if err := r.db.Model(&ItemModel{}).Scopes(scopes...).Where(condition).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	items, err := r.getItems(condition, append(scopes, itemSortScope(opts), itemPageScope(opts), ItemTagsScope)...)
+	return items, total, err
+}
+
+func itemFilterScope(opts ItemListOptions) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if opts.Status != "" {
+			db = db.Where("status = ?", opts.Status)
+		}
+		if opts.MinPrice != nil {
+			db = db.Where("price >= ?", *opts.MinPrice)
+		}
+		if opts.MaxPrice != nil {
+			db = db.Where("price <= ?", *opts.MaxPrice)
+		}
+		if opts.Tag != "" {
+			db = taggedScope(opts.Tag)(db)
+		}
+		if opts.Name != "" {
+			db = db.Where("LOWER(name) LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(strings.ToLower(opts.Name))+"%")
+		}
+		return db
+	}
+}
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// itemSortScope orders by the chosen sort key, using the id to keep the order
+// stable between pages. Without a sort key it falls back to ItemOrderScope.
+func itemSortScope(opts ItemListOptions) func(*gorm.DB) *gorm.DB {
+	column, ok := itemSortColumns[opts.Sort]
+	if !ok {
+		return ItemOrderScope
+	}
+	direction := "ASC"
+	if opts.Desc {
+		direction = "DESC"
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Order(fmt.Sprintf("%s %s, id %s", quoted(db, column), direction, direction))
+	}
+}
+
+func itemPageScope(opts ItemListOptions) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if opts.Limit > 0 {
+			db = db.Limit(opts.Limit)
+		}
+		if opts.Offset > 0 {
+			db = db.Offset(opts.Offset)
+		}
+		return db
+	}
+}
+
+func (r *itemRepository) GetWishlistWantedItems(listID int, opts ItemListOptions) (*[]ItemModel, int, error) {
+	condition := map[string]interface{}{"reserved": false, "status": ItemStatusWanted, "wishlistid": listID}
+	return r.getItemsPage(condition, opts, ItemDefaultScope)
+}
+
+func (r *itemRepository) GetWishlistAllItems(listID int, opts ItemListOptions) (*[]ItemModel, int, error) {
+	condition := map[string]interface{}{"wishlistid": listID}
+	return r.getItemsPage(condition, opts, ItemDefaultScope)
+}
+
+// GetWishlistActiveItems returns the items on the list that are wanted,
+// reserved or purchased, leaving out those received or archived.
+func (r *itemRepository) GetWishlistActiveItems(listID int, opts ItemListOptions) (*[]ItemModel, int, error) {
+	condition := map[string]interface{}{"wishlistid": listID}
+	return r.getItemsPage(condition, opts, ItemDefaultScope, activeItemsScope)
+}
+
+func activeItemsScope(db *gorm.DB) *gorm.DB {
+	return db.Where("status IN (?)", []string{ItemStatusWanted, ItemStatusReserved, ItemStatusPurchased})
+}
+
+func (r *itemRepository) GetWishlistReservedItems(listID, userID int, opts ItemListOptions) (*[]ItemModel, int, error) {
+	condition := map[string]interface{}{"wishlistid": listID}
+	return r.getItemsPage(condition, opts, ItemDefaultScope, ItemUnscopedScope, reservedByScope(userID))
+}
+
+// reservedByScope limits items to those the user holds a reservation for.
+func reservedByScope(userID int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		reservedIDs := db.New().Model(&ReservationModel{}).Select("itemid").Where("userid = ?", userID)
+		// SubQuery adds its own parentheses. Wrapping it again makes it a
+		// scalar subquery, which only matches the first reservation.
+		return db.Where("id IN ?", reservedIDs.SubQuery())
+	}
+}
+
+func (r *itemRepository) AddItem(newItem *ItemModel) error {
+	err := r.db.Create(newItem).Error
+	return err
+}
+
+// AddItems creates all of the items, or none of them if any fail. The items
+// are tagged with their Tags, which must already exist.
+func (r *itemRepository) AddItems(newItems []ItemModel) error {
+	tx := r.db.Set("gorm:association_autocreate", false).Set("gorm:association_autoupdate", false).Begin()
+	for i := range newItems {
+		if err := tx.Create(&newItems[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+// DeleteItem soft deletes the item. Its reservations are kept so reservers
+// can see it was removed.
+func (r *itemRepository) DeleteItem(item *ItemModel) error {
+	err := r.db.Delete(&item).Error
 	return err
 }
 
-func DeleteItem(item *ItemModel) error {
-	db := db.GetDB()
-	err := db.Delete(&item).Error
+// RestoreItem undoes a soft delete.
+func (r *itemRepository) RestoreItem(item *ItemModel) error {
+	err := r.db.Unscoped().Model(item).Updates(map[string]interface{}{"deletedAt": nil}).Error
 	return err
 }
 
-func UpdateItemWithMap(item *ItemModel, updates map[string]interface{}) error {
-	db := db.GetDB()
-	err := db.Model(item).Updates(updates).Error
+func (r *itemRepository) UpdateItem(item *ItemModel, updates map[string]interface{}) error {
+	err := r.db.Model(item).Updates(updates).Error
 	return err
 }
+
+// UpdateItemStatus moves the item from one status to another. The update only
+// applies if the item is still in the from status, returns false otherwise.
+// Reservers move items to purchased, so the items updatedAt is left alone for
+// the same reason as in setItemReservedFlag.
+func (r *itemRepository) UpdateItemStatus(item *ItemModel, from, to string) (bool, error) {
+	update := r.db.Model(&ItemModel{}).Where("id = ? AND status = ?", item.ID, from).
+		UpdateColumn("status", to)
+	return update.RowsAffected > 0, update.Error
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func testItemNames(items *[]ItemModel) string {
+	names := []string{}
+	for _, item := range *items {
+		names = append(names, item.Name)
+	}
+	return fmt.Sprint(names)
+}
+
+func TestItemListings(t *testing.T) {
+	conn := newTestDB(t)
+	defer conn.Close()
+	users, items, lists := NewUserRepository(conn), NewItemRepository(conn), NewWishlistRepository(conn)
+	owner := createTestUser(t, users, "owner")
+	list := &WishlistModel{OwnerID: owner.ID, Title: "Birthday"}
+	if err := lists.CreateWishlist(list); err != nil {
+		t.Fatalf("Creating the wishlist failed: %s", err.Error())
+	}
+
+	price := func(p float64) *float64 { return &p }
+	createTestItem(t, items, &ItemModel{Name: "Lamp", OwnerID: &owner.ID, Rank: 1, Price: price(30)})
+	createTestItem(t, items, &ItemModel{Name: "Mug", OwnerID: &owner.ID, Rank: 2, Price: price(5)})
+	createTestItem(t, items, &ItemModel{Name: "Book", OwnerID: &owner.ID, WishlistID: &list.ID, Rank: 1, Price: price(15)})
+	createTestItem(t, items, &ItemModel{Name: "Bike", OwnerID: &owner.ID, WishlistID: &list.ID, Rank: 2, Status: ItemStatusPurchased})
+	createTestItem(t, items, &ItemModel{Name: "Boots", OwnerID: &owner.ID, WishlistID: &list.ID, Rank: 3, Status: ItemStatusReceived})
+
+	for _, test := range []struct {
+		name  string
+		get   func() (*[]ItemModel, int, error)
+		want  string
+		total int
+	}{
+		{"wanted", func() (*[]ItemModel, int, error) { return items.GetWantedItems(ItemListOptions{}) },
+			"[Lamp Mug]", 2},
+		{"wanted cheap", func() (*[]ItemModel, int, error) { return items.GetWantedItems(ItemListOptions{MaxPrice: price(10)}) },
+			"[Mug]", 1},
+		{"all by price", func() (*[]ItemModel, int, error) {
+			return items.GetAllItems(ItemListOptions{Sort: ItemSortPrice, Desc: true, Limit: 2})
+		}, "[Lamp Book]", 5},
+		{"all second page", func() (*[]ItemModel, int, error) {
+			return items.GetAllItems(ItemListOptions{Sort: ItemSortPrice, Desc: true, Limit: 2, Offset: 2})
+		}, "[Mug Boots]", 5},
+		{"named like b_ escaped", func() (*[]ItemModel, int, error) { return items.GetAllItems(ItemListOptions{Name: "b_"}) },
+			"[]", 0},
+		{"list wanted", func() (*[]ItemModel, int, error) { return items.GetWishlistWantedItems(list.ID, ItemListOptions{}) },
+			"[Book]", 1},
+		{"list active", func() (*[]ItemModel, int, error) { return items.GetWishlistActiveItems(list.ID, ItemListOptions{}) },
+			"[Book Bike]", 2},
+		{"list all received", func() (*[]ItemModel, int, error) {
+			return items.GetWishlistAllItems(list.ID, ItemListOptions{Status: ItemStatusReceived})
+		}, "[Boots]", 1},
+	} {
+		got, total, err := test.get()
+		if err != nil {
+			t.Errorf("Listing %s failed: %s", test.name, err.Error())
+			continue
+		}
+		if testItemNames(got) != test.want || total != test.total {
+			t.Errorf("Listing %s returned %v of %v, want %v of %v", test.name, testItemNames(got), total, test.want, test.total)
+		}
+	}
+}
+
+func TestReservedItems(t *testing.T) {
+	conn := newTestDB(t)
+	defer conn.Close()
+	users, items := NewUserRepository(conn), NewItemRepository(conn)
+	owner := createTestUser(t, users, "owner")
+	reserver := createTestUser(t, users, "reserver")
+
+	var book *ItemModel
+	for _, name := range []string{"Lamp", "Mug", "Book", "Bike"} {
+		item := createTestItem(t, items, &ItemModel{Name: name, OwnerID: &owner.ID, Quantity: 2})
+		if name == "Book" {
+			book = item
+		}
+		if name == "Bike" {
+			continue
+		}
+		if reserved, err := items.ReserveItem(item, reserver.ID, 1); err != nil || !reserved {
+			t.Fatalf("Reserving %s returned %v, %v", name, reserved, err)
+		}
+	}
+	if err := items.DeleteItem(book); err != nil {
+		t.Fatalf("Deleting the book failed: %s", err.Error())
+	}
+
+	// Every reservation counts, not just the first, and deleted items are
+	// still listed for their reservers.
+	reserved, total, err := items.GetReservedItems(reserver.ID, ItemListOptions{})
+	if err != nil {
+		t.Fatalf("GetReservedItems failed: %s", err.Error())
+	}
+	if testItemNames(reserved) != "[Book Mug Lamp]" || total != 3 {
+		t.Errorf("GetReservedItems returned %v of %v, want [Book Mug Lamp] of 3", testItemNames(reserved), total)
+	}
+	if (*reserved)[0].DeletedAt == nil {
+		t.Error("The deleted book isn't marked as deleted")
+	}
+	if reserved, _, _ := items.GetReservedItems(owner.ID, ItemListOptions{}); len(*reserved) != 0 {
+		t.Errorf("The owner has %v reserved items, want none", len(*reserved))
+	}
+}
+
+func TestReserveAndUnReserveItem(t *testing.T) {
+	conn := newTestDB(t)
+	defer conn.Close()
+	users, items := NewUserRepository(conn), NewItemRepository(conn)
+	owner := createTestUser(t, users, "owner")
+	reserver := createTestUser(t, users, "reserver")
+	item := createTestItem(t, items, &ItemModel{Name: "Socks", OwnerID: &owner.ID, Quantity: 2})
+
+	for i := 0; i < 2; i++ {
+		if reserved, err := items.ReserveItem(item, reserver.ID, 1); err != nil || !reserved {
+			t.Fatalf("Reserving returned %v, %v", reserved, err)
+		}
+	}
+	if reserved, err := items.ReserveItem(item, reserver.ID, 1); err != nil || reserved {
+		t.Errorf("Reserving more than the quantity returned %v, %v, want false", reserved, err)
+	}
+	reservation, err := items.FindReservation(item.ID, reserver.ID)
+	if err != nil || reservation == nil || reservation.Quantity != 2 {
+		t.Fatalf("FindReservation returned %v, %v, want a quantity of 2", reservation, err)
+	}
+	found, _ := items.FindItem(item.ID, false)
+	if !found.Reserved || found.Status != ItemStatusReserved {
+		t.Errorf("Fully reserved item has reserved %v and status %v", found.Reserved, found.Status)
+	}
+
+	stale := *reservation
+	stale.Quantity = 1
+	if released, err := items.UnReserveItem(found, &stale); err != nil || released {
+		t.Errorf("Releasing a changed reservation returned %v, %v, want false", released, err)
+	}
+	if released, err := items.UnReserveItem(found, reservation); err != nil || !released {
+		t.Fatalf("UnReserveItem returned %v, %v", released, err)
+	}
+	found, _ = items.FindItem(item.ID, false)
+	if found.Reserved || found.ReservedQuantity != 0 || found.Status != ItemStatusWanted {
+		t.Errorf("Released item has reserved %v, reservedqty %v and status %v", found.Reserved, found.ReservedQuantity, found.Status)
+	}
+
+	// Deleted items are released whatever their status.
+	if reserved, err := items.ReserveItem(found, reserver.ID, 2); err != nil || !reserved {
+		t.Fatalf("Reserving returned %v, %v", reserved, err)
+	}
+	if updated, err := items.UpdateItemStatus(found, ItemStatusReserved, ItemStatusPurchased); err != nil || !updated {
+		t.Fatalf("UpdateItemStatus returned %v, %v", updated, err)
+	}
+	if err := items.DeleteItem(found); err != nil {
+		t.Fatalf("DeleteItem failed: %s", err.Error())
+	}
+	reservation, _ = items.FindReservation(item.ID, reserver.ID)
+	if released, err := items.UnReserveItem(found, reservation); err != nil || !released {
+		t.Fatalf("UnReserveItem of a deleted item returned %v, %v", released, err)
+	}
+	found, _ = items.FindItem(item.ID, true)
+	if found.Reserved || found.ReservedQuantity != 0 || found.Status != ItemStatusPurchased {
+		t.Errorf("Released deleted item has reserved %v, reservedqty %v and status %v", found.Reserved, found.ReservedQuantity, found.Status)
+	}
+}
+
+func TestItemStatusAndDelete(t *testing.T) {
+	conn := newTestDB(t)
+	defer conn.Close()
+	users, items := NewUserRepository(conn), NewItemRepository(conn)
+	owner := createTestUser(t, users, "owner")
+	item := createTestItem(t, items, &ItemModel{Name: "Socks", OwnerID: &owner.ID})
+
+	if updated, err := items.UpdateItemStatus(item, ItemStatusReserved, ItemStatusPurchased); err != nil || updated {
+		t.Errorf("Updating from the wrong status returned %v, %v, want false", updated, err)
+	}
+	before, _ := items.FindItem(item.ID, false)
+	if updated, err := items.UpdateItemStatus(item, ItemStatusWanted, ItemStatusArchived); err != nil || !updated {
+		t.Errorf("UpdateItemStatus returned %v, %v", updated, err)
+	}
+	if found, _ := items.FindItem(item.ID, false); !found.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Errorf("UpdateItemStatus changed updatedAt from %v to %v", before.UpdatedAt, found.UpdatedAt)
+	}
+
+	if err := items.DeleteItem(item); err != nil {
+		t.Fatalf("DeleteItem failed: %s", err.Error())
+	}
+	if found, err := items.FindItem(item.ID, false); err != nil || found != nil {
+		t.Errorf("FindItem of a deleted item returned %v, %v, want nil, nil", found, err)
+	}
+	if found, err := items.FindItem(item.ID, true); err != nil || found == nil || found.DeletedAt == nil {
+		t.Fatalf("FindItem with deleted returned %v, %v", found, err)
+	}
+	if err := items.RestoreItem(item); err != nil {
+		t.Fatalf("RestoreItem failed: %s", err.Error())
+	}
+	if found, _ := items.FindItem(item.ID, false); found == nil || found.Status != ItemStatusArchived {
+		t.Errorf("Restored item is %v, want it archived", found)
+	}
+}
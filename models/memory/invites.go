@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/jatgam/wishlist-api/models"
+)
+
+func (s *Store) FindInvite(inviteID int) (*models.InviteModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	invite, ok := s.invites[inviteID]
+	if !ok {
+		return nil, nil
+	}
+	return &invite, nil
+}
+
+// GetInvites returns every invite, the newest first.
+func (s *Store) GetInvites() (*[]models.InviteModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	invites := []models.InviteModel{}
+	ids := sortedIDs(s.invites)
+	for i := len(ids) - 1; i >= 0; i-- {
+		invites = append(invites, s.invites[ids[i]])
+	}
+	return &invites, nil
+}
+
+func (s *Store) CreateInvite(newInvite *models.InviteModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, invite := range s.invites {
+		if invite.Code == newInvite.Code {
+			return ErrDuplicate
+		}
+	}
+	s.newRecord("invites", &newInvite.DefaultModel)
+	s.invites[newInvite.ID] = *newInvite
+	return nil
+}
+
+func (s *Store) DeleteInvite(invite *models.InviteModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.invites, invite.ID)
+	return nil
+}
+
+// UseInvite counts a use of the code. The use only counts if the code has
+// uses left and hasn't expired at the time given, returns false otherwise.
+func (s *Store) UseInvite(code string, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, invite := range s.invites {
+		if invite.Code != code {
+			continue
+		}
+		if invite.Uses >= invite.MaxUses || (invite.ExpiresAt != nil && !invite.ExpiresAt.After(now)) {
+			return false, nil
+		}
+		invite.Uses++
+		invite.UpdatedAt = now
+		s.invites[id] = invite
+		return true, nil
+	}
+	return false, nil
+}
+
+func (s *Store) ReleaseInvite(code string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, invite := range s.invites {
+		if invite.Code == code && invite.Uses > 0 {
+			invite.Uses--
+			invite.UpdatedAt = time.Now()
+			s.invites[id] = invite
+		}
+	}
+	return nil
+}
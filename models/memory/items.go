@@ -0,0 +1,299 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jatgam/wishlist-api/models"
+)
+
+func (s *Store) FindItem(itemID int, withDeleted bool) (*models.ItemModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[itemID]
+	if !ok || (item.DeletedAt != nil && !withDeleted) {
+		return nil, nil
+	}
+	return &item, nil
+}
+
+// GetWantedItems returns the unreserved items that are not part of a named
+// wishlist, along with the total number matching the options.
+func (s *Store) GetWantedItems(opts models.ItemListOptions) (*[]models.ItemModel, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.getItems(false, func(item models.ItemModel) bool {
+		return !item.Reserved && item.Status == models.ItemStatusWanted && item.WishlistID == nil
+	})
+	return s.itemsPage(items, opts)
+}
+
+func (s *Store) GetAllItems(opts models.ItemListOptions) (*[]models.ItemModel, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.getItems(false, func(item models.ItemModel) bool { return true })
+	return s.itemsPage(items, opts)
+}
+
+// GetReservedItems returns the items the user holds a reservation for,
+// including items the owner has since deleted.
+func (s *Store) GetReservedItems(userID int, opts models.ItemListOptions) (*[]models.ItemModel, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.getItems(true, func(item models.ItemModel) bool { return s.reservedBy(item.ID, userID) })
+	return s.itemsPage(items, opts)
+}
+
+func (s *Store) GetWishlistWantedItems(listID int, opts models.ItemListOptions) (*[]models.ItemModel, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.getItems(false, func(item models.ItemModel) bool {
+		return !item.Reserved && item.Status == models.ItemStatusWanted && inWishlist(item, listID)
+	})
+	return s.itemsPage(items, opts)
+}
+
+func (s *Store) GetWishlistAllItems(listID int, opts models.ItemListOptions) (*[]models.ItemModel, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.getItems(false, func(item models.ItemModel) bool { return inWishlist(item, listID) })
+	return s.itemsPage(items, opts)
+}
+
+// GetWishlistActiveItems returns the items on the list that are wanted,
+// reserved or purchased, leaving out those received or archived.
+func (s *Store) GetWishlistActiveItems(listID int, opts models.ItemListOptions) (*[]models.ItemModel, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.getItems(false, func(item models.ItemModel) bool {
+		switch item.Status {
+		case models.ItemStatusWanted, models.ItemStatusReserved, models.ItemStatusPurchased:
+			return inWishlist(item, listID)
+		}
+		return false
+	})
+	return s.itemsPage(items, opts)
+}
+
+func (s *Store) GetWishlistReservedItems(listID, userID int, opts models.ItemListOptions) (*[]models.ItemModel, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := s.getItems(true, func(item models.ItemModel) bool {
+		return inWishlist(item, listID) && s.reservedBy(item.ID, userID)
+	})
+	return s.itemsPage(items, opts)
+}
+
+func inWishlist(item models.ItemModel, listID int) bool {
+	return item.WishlistID != nil && *item.WishlistID == listID
+}
+
+// getItems returns copies of the matching items, leaving out soft deleted ones
+// unless asked for.
+func (s *Store) getItems(withDeleted bool, match func(models.ItemModel) bool) []models.ItemModel {
+	items := []models.ItemModel{}
+	for _, id := range sortedIDs(s.items) {
+		item := s.items[id]
+		if (item.DeletedAt == nil || withDeleted) && match(item) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// itemsPage filters the items by the options, then sorts and pages them.
+func (s *Store) itemsPage(items []models.ItemModel, opts models.ItemListOptions) (*[]models.ItemModel, int, error) {
+	filtered := []models.ItemModel{}
+	for _, item := range items {
+		if s.itemMatches(item, opts) {
+			filtered = append(filtered, item)
+		}
+	}
+	sortItems(filtered, opts)
+	total := len(filtered)
+	if opts.Offset > 0 {
+		if opts.Offset > len(filtered) {
+			opts.Offset = len(filtered)
+		}
+		filtered = filtered[opts.Offset:]
+	}
+	if opts.Limit > 0 && opts.Limit < len(filtered) {
+		filtered = filtered[:opts.Limit]
+	}
+	s.loadTags(filtered)
+	return &filtered, total, nil
+}
+
+func (s *Store) itemMatches(item models.ItemModel, opts models.ItemListOptions) bool {
+	if opts.Status != "" && item.Status != opts.Status {
+		return false
+	}
+	if opts.MinPrice != nil && (item.Price == nil || *item.Price < *opts.MinPrice) {
+		return false
+	}
+	if opts.MaxPrice != nil && (item.Price == nil || *item.Price > *opts.MaxPrice) {
+		return false
+	}
+	if opts.Tag != "" && !s.taggedWith(item.ID, opts.Tag) {
+		return false
+	}
+	if opts.Name != "" && !strings.Contains(strings.ToLower(item.Name), strings.ToLower(opts.Name)) {
+		return false
+	}
+	return true
+}
+
+// sortItems orders by the chosen sort key with the id as a tie break, or by
+// rank and newest first without one, the same as the database listings.
+func sortItems(items []models.ItemModel, opts models.ItemListOptions) {
+	var compare func(a, b models.ItemModel) int
+	switch opts.Sort {
+	case models.ItemSortRank:
+		compare = func(a, b models.ItemModel) int { return compareInts(a.Rank, b.Rank) }
+	case models.ItemSortCreated:
+		compare = func(a, b models.ItemModel) int {
+			switch {
+			case a.CreatedAt.Before(b.CreatedAt):
+				return -1
+			case a.CreatedAt.After(b.CreatedAt):
+				return 1
+			}
+			return 0
+		}
+	case models.ItemSortPrice:
+		// Items without a price sort first, as they do in mysql and sqlite.
+		compare = func(a, b models.ItemModel) int {
+			switch {
+			case a.Price == nil && b.Price == nil:
+				return 0
+			case a.Price == nil:
+				return -1
+			case b.Price == nil:
+				return 1
+			case *a.Price < *b.Price:
+				return -1
+			case *a.Price > *b.Price:
+				return 1
+			}
+			return 0
+		}
+	default:
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].Rank != items[j].Rank {
+				return items[i].Rank < items[j].Rank
+			}
+			return items[i].ID > items[j].ID
+		})
+		return
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		order := compare(items[i], items[j])
+		if order == 0 {
+			order = compareInts(items[i].ID, items[j].ID)
+		}
+		if opts.Desc {
+			return order > 0
+		}
+		return order < 0
+	})
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (s *Store) AddItem(newItem *models.ItemModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addItem(newItem)
+	return nil
+}
+
+// AddItems creates all of the items. Nothing can fail part way, so there is
+// nothing to roll back.
+func (s *Store) AddItems(newItems []models.ItemModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range newItems {
+		s.addItem(&newItems[i])
+	}
+	return nil
+}
+
+func (s *Store) addItem(newItem *models.ItemModel) {
+	if newItem.Status == "" {
+		newItem.Status = models.ItemStatusWanted
+	}
+	if newItem.Quantity == 0 {
+		newItem.Quantity = 1
+	}
+	s.newRecord("items", &newItem.DefaultModel)
+	item := *newItem
+	item.Tags = nil
+	s.items[item.ID] = item
+	for _, tag := range newItem.Tags {
+		if s.itemTags[item.ID] == nil {
+			s.itemTags[item.ID] = map[int]bool{}
+		}
+		s.itemTags[item.ID][tag.ID] = true
+	}
+}
+
+// DeleteItem soft deletes the item. Its reservations are kept so reservers
+// can see it was removed.
+func (s *Store) DeleteItem(item *models.ItemModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.items[item.ID]
+	if !ok || stored.DeletedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	stored.DeletedAt = &now
+	s.items[item.ID] = stored
+	return nil
+}
+
+// RestoreItem undoes a soft delete.
+func (s *Store) RestoreItem(item *models.ItemModel) error {
+	return s.UpdateItem(item, map[string]interface{}{"deletedAt": nil})
+}
+
+func (s *Store) UpdateItem(item *models.ItemModel, updates map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.items[item.ID]
+	if !ok {
+		return nil
+	}
+	if err := applyUpdates(&stored, updates); err != nil {
+		return err
+	}
+	stored.UpdatedAt = time.Now()
+	s.items[item.ID] = stored
+	applyUpdates(item, updates)
+	item.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// UpdateItemStatus moves the item from one status to another. The update only
+// applies if the item is still in the from status, returns false otherwise.
+// The items updatedAt is left alone, see setReservedFlag.
+func (s *Store) UpdateItemStatus(item *models.ItemModel, from, to string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.items[item.ID]
+	if !ok || stored.DeletedAt != nil || stored.Status != from {
+		return false, nil
+	}
+	stored.Status = to
+	s.items[item.ID] = stored
+	return true, nil
+}
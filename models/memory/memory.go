@@ -0,0 +1,76 @@
+// Package memory keeps the repositories in memory, so the services can be run
+// without a database. It follows the behaviour of the database repositories,
+// down to soft deletes and the order of listings.
+package memory
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jatgam/wishlist-api/models"
+)
+
+// ErrDuplicate is returned when a unique field is already taken, where the
+// database would reject the insert.
+var ErrDuplicate = errors.New("duplicate entry for a unique field")
+
+var (
+	_ models.UserRepository     = (*Store)(nil)
+	_ models.ItemRepository     = (*Store)(nil)
+	_ models.WishlistRepository = (*Store)(nil)
+	_ models.TagRepository      = (*Store)(nil)
+	_ models.InviteRepository   = (*Store)(nil)
+)
+
+// Store implements every repository. Records are copied in and out, so
+// callers never share them with the store.
+type Store struct {
+	mu           sync.Mutex
+	lastIDs      map[string]int
+	users        map[int]models.UserModel
+	items        map[int]models.ItemModel
+	reservations map[int]models.ReservationModel
+	wishlists    map[int]models.WishlistModel
+	tags         map[int]models.TagModel
+	// itemTags holds the IDs of the tags on each item.
+	itemTags map[int]map[int]bool
+	invites  map[int]models.InviteModel
+}
+
+// NewStore creates an empty Store.
+func NewStore() *Store {
+	return &Store{
+		lastIDs:      map[string]int{},
+		users:        map[int]models.UserModel{},
+		items:        map[int]models.ItemModel{},
+		reservations: map[int]models.ReservationModel{},
+		wishlists:    map[int]models.WishlistModel{},
+		tags:         map[int]models.TagModel{},
+		itemTags:     map[int]map[int]bool{},
+		invites:      map[int]models.InviteModel{},
+	}
+}
+
+// newRecord gives a new record of the table its ID and timestamps.
+func (s *Store) newRecord(table string, record *models.DefaultModel) {
+	s.lastIDs[table]++
+	now := time.Now()
+	record.ID = s.lastIDs[table]
+	record.CreatedAt = now
+	record.UpdatedAt = now
+}
+
+// sortedIDs returns the keys of a map of records in id order, as the
+// database returns rows without an order.
+func sortedIDs(records interface{}) []int {
+	keys := reflect.ValueOf(records).MapKeys()
+	ids := make([]int, len(keys))
+	for i, key := range keys {
+		ids[i] = int(key.Int())
+	}
+	sort.Ints(ids)
+	return ids
+}
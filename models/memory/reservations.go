@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/jatgam/wishlist-api/models"
+)
+
+func (s *Store) GetItemReservations(itemID int) (*[]models.ReservationModel, error) {
+	return s.getReservations(func(reservation models.ReservationModel) bool { return reservation.ItemID == itemID })
+}
+
+func (s *Store) GetUserReservations(userID int) (*[]models.ReservationModel, error) {
+	return s.getReservations(func(reservation models.ReservationModel) bool { return reservation.UserID == userID })
+}
+
+func (s *Store) getReservations(match func(models.ReservationModel) bool) (*[]models.ReservationModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reservations := []models.ReservationModel{}
+	for _, id := range sortedIDs(s.reservations) {
+		if reservation := s.reservations[id]; match(reservation) {
+			reservations = append(reservations, reservation)
+		}
+	}
+	return &reservations, nil
+}
+
+func (s *Store) FindReservation(itemID, userID int) (*models.ReservationModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reservation, ok := s.findReservation(itemID, userID)
+	if !ok {
+		return nil, nil
+	}
+	return &reservation, nil
+}
+
+func (s *Store) findReservation(itemID, userID int) (models.ReservationModel, bool) {
+	for _, reservation := range s.reservations {
+		if reservation.ItemID == itemID && reservation.UserID == userID {
+			return reservation, true
+		}
+	}
+	return models.ReservationModel{}, false
+}
+
+func (s *Store) reservedBy(itemID, userID int) bool {
+	_, ok := s.findReservation(itemID, userID)
+	return ok
+}
+
+// ReserveItem adds quantity to the users reservation of the item and updates
+// the items reserved totals. Returns false if the item no longer has enough
+// remaining.
+func (s *Store) ReserveItem(item *models.ItemModel, userID, quantity int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.items[item.ID]
+	if !ok || stored.DeletedAt != nil || stored.Status != models.ItemStatusWanted ||
+		stored.ReservedQuantity+quantity > stored.Quantity {
+		return false, nil
+	}
+	stored.ReservedQuantity += quantity
+	s.items[item.ID] = setReservedFlag(stored)
+
+	reservation, ok := s.findReservation(item.ID, userID)
+	if !ok {
+		reservation = models.ReservationModel{ItemID: item.ID, UserID: userID}
+		s.newRecord("reservations", &reservation.DefaultModel)
+	} else {
+		reservation.UpdatedAt = time.Now()
+	}
+	reservation.Quantity += quantity
+	s.reservations[reservation.ID] = reservation
+	return true, nil
+}
+
+// UpdateItemQuantity applies the updates along with the new quantity, as long
+// as the quantity still covers what is reserved, and moves the items reserved
+// totals to match. Returns false if more of the item has been reserved since.
+func (s *Store) UpdateItemQuantity(item *models.ItemModel, quantity int, updates map[string]interface{}) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.items[item.ID]
+	if !ok {
+		return true, nil
+	}
+	if stored.ReservedQuantity > quantity {
+		return false, nil
+	}
+	if err := applyUpdates(&stored, updates); err != nil {
+		return false, err
+	}
+	stored.Quantity = quantity
+	stored.UpdatedAt = time.Now()
+	s.items[item.ID] = setReservedFlag(stored)
+	applyUpdates(item, updates)
+	item.Quantity, item.UpdatedAt = quantity, stored.UpdatedAt
+	return true, nil
+}
+
+// UnReserveItem removes the reservation and releases its quantity back to the
+// item. Returns false if the reservation was changed or removed since it was
+// read, or the item has moved past reserved. Deleted items are released
+// whatever their status.
+func (s *Store) UnReserveItem(item *models.ItemModel, reservation *models.ReservationModel) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.reservations[reservation.ID]
+	if !ok || stored.Quantity != reservation.Quantity {
+		return false, nil
+	}
+	storedItem, ok := s.items[item.ID]
+	if !ok || storedItem.ReservedQuantity < reservation.Quantity || (storedItem.DeletedAt == nil &&
+		storedItem.Status != models.ItemStatusWanted && storedItem.Status != models.ItemStatusReserved) {
+		return false, nil
+	}
+	delete(s.reservations, reservation.ID)
+	storedItem.ReservedQuantity -= reservation.Quantity
+	s.items[item.ID] = setReservedFlag(storedItem)
+	return true, nil
+}
+
+// setReservedFlag marks the item reserved once its full quantity is claimed,
+// and moves its status between wanted and reserved to match. The items
+// updatedAt is left alone so it doesn't hint at reservations on surprise
+// wishlists.
+func setReservedFlag(item models.ItemModel) models.ItemModel {
+	item.Reserved = item.ReservedQuantity >= item.Quantity
+	if item.Status == models.ItemStatusWanted || item.Status == models.ItemStatusReserved {
+		if item.Reserved {
+			item.Status = models.ItemStatusReserved
+		} else {
+			item.Status = models.ItemStatusWanted
+		}
+	}
+	return item
+}
@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jatgam/wishlist-api/models"
+)
+
+func (s *Store) FindTag(tagID int) (*models.TagModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tag, ok := s.tags[tagID]
+	if !ok {
+		return nil, nil
+	}
+	return &tag, nil
+}
+
+func (s *Store) FindTagByName(ownerID int, name string) (*models.TagModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range sortedIDs(s.tags) {
+		if tag := s.tags[id]; tag.OwnerID == ownerID && tag.Name == name {
+			return &tag, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetTags returns the owners tags in name order.
+func (s *Store) GetTags(ownerID int) (*[]models.TagModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tags := []models.TagModel{}
+	for _, tag := range s.tags {
+		if tag.OwnerID == ownerID {
+			tags = append(tags, tag)
+		}
+	}
+	sortTags(tags)
+	return &tags, nil
+}
+
+func (s *Store) CreateTag(newTag *models.TagModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tagNameTaken(newTag.OwnerID, newTag.Name, 0) {
+		return ErrDuplicate
+	}
+	s.newRecord("tags", &newTag.DefaultModel)
+	s.tags[newTag.ID] = *newTag
+	return nil
+}
+
+func (s *Store) UpdateTag(tag *models.TagModel, updates map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.tags[tag.ID]
+	if !ok {
+		return nil
+	}
+	if err := applyUpdates(&stored, updates); err != nil {
+		return err
+	}
+	if s.tagNameTaken(stored.OwnerID, stored.Name, stored.ID) {
+		return ErrDuplicate
+	}
+	stored.UpdatedAt = time.Now()
+	s.tags[tag.ID] = stored
+	applyUpdates(tag, updates)
+	tag.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// tagNameTaken reports whether the owner has another tag with the name.
+func (s *Store) tagNameTaken(ownerID int, name string, exceptID int) bool {
+	for _, tag := range s.tags {
+		if tag.OwnerID == ownerID && tag.Name == name && tag.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteTag removes the tag from every item it was on, then the tag.
+func (s *Store) DeleteTag(tag *models.TagModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tagIDs := range s.itemTags {
+		delete(tagIDs, tag.ID)
+	}
+	delete(s.tags, tag.ID)
+	return nil
+}
+
+// TagItem adds the tag to the item. Tagging an item twice is a no-op.
+func (s *Store) TagItem(item *models.ItemModel, tag *models.TagModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.itemTags[item.ID] == nil {
+		s.itemTags[item.ID] = map[int]bool{}
+	}
+	s.itemTags[item.ID][tag.ID] = true
+	return nil
+}
+
+func (s *Store) UntagItem(item *models.ItemModel, tag *models.TagModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.itemTags[item.ID], tag.ID)
+	return nil
+}
+
+// loadTags sets the tags of each item, in name order.
+func (s *Store) loadTags(items []models.ItemModel) {
+	for i := range items {
+		tags := []models.TagModel{}
+		for tagID := range s.itemTags[items[i].ID] {
+			tags = append(tags, s.tags[tagID])
+		}
+		sortTags(tags)
+		items[i].Tags = tags
+	}
+}
+
+// taggedWith reports whether the item has a tag of the given name, ignoring
+// case.
+func (s *Store) taggedWith(itemID int, name string) bool {
+	for tagID := range s.itemTags[itemID] {
+		if strings.EqualFold(s.tags[tagID].Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortTags(tags []models.TagModel) {
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+}
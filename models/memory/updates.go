@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// applyUpdates sets the fields of the model from the updates map, the way
+// gorm does. Keys are field names or gorm column names, and a nil value
+// clears the field.
+func applyUpdates(model interface{}, updates map[string]interface{}) error {
+	record := reflect.ValueOf(model).Elem()
+	for key, value := range updates {
+		field, ok := findField(record, key)
+		if !ok {
+			return fmt.Errorf("%s has no field %s", record.Type().Name(), key)
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("can't set %s: %s", key, err.Error())
+		}
+	}
+	return nil
+}
+
+// findField looks for the field by name or column, including the fields of
+// embedded structs.
+func findField(record reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < record.NumField(); i++ {
+		structField := record.Type().Field(i)
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct {
+			if field, ok := findField(record.Field(i), key); ok {
+				return field, true
+			}
+			continue
+		}
+		if structField.Name == key || columnName(structField) == key {
+			return record.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func columnName(structField reflect.StructField) string {
+	for _, setting := range strings.Split(structField.Tag.Get("gorm"), ";") {
+		if strings.HasPrefix(setting, "column:") {
+			return strings.TrimPrefix(setting, "column:")
+		}
+	}
+	return ""
+}
+
+// setField assigns the value, dereferencing or taking the address of it as the
+// field needs. Values are copied so the record never shares them.
+func setField(field reflect.Value, value interface{}) error {
+	newValue := reflect.ValueOf(value)
+	if value == nil || (newValue.Kind() == reflect.Ptr && newValue.IsNil()) {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	if newValue.Kind() == reflect.Ptr {
+		newValue = newValue.Elem()
+	}
+	fieldType := field.Type()
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if !newValue.Type().ConvertibleTo(fieldType) {
+		return fmt.Errorf("%s is not a %s", newValue.Type(), fieldType)
+	}
+	newValue = newValue.Convert(fieldType)
+	if field.Kind() == reflect.Ptr {
+		pointer := reflect.New(fieldType)
+		pointer.Elem().Set(newValue)
+		newValue = pointer
+	}
+	field.Set(newValue)
+	return nil
+}
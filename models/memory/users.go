@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/jatgam/wishlist-api/models"
+)
+
+func (s *Store) FindUser(userID int) (*models.UserModel, error) {
+	return s.findOneUser(func(user models.UserModel) bool { return user.ID == userID })
+}
+
+func (s *Store) FindUserForAuth(userID int) (*models.UserModel, error) {
+	return s.FindUser(userID)
+}
+
+func (s *Store) FindUserByUsername(username string) (*models.UserModel, error) {
+	return s.findOneUser(func(user models.UserModel) bool { return user.Username == username })
+}
+
+func (s *Store) FindUserByEMail(email string) (*models.UserModel, error) {
+	return s.findOneUser(func(user models.UserModel) bool { return user.EMail == email })
+}
+
+func (s *Store) FindUserByResetToken(token string) (*models.UserModel, error) {
+	return s.findOneUser(func(user models.UserModel) bool {
+		return user.PasswordResetToken != nil && *user.PasswordResetToken == token
+	})
+}
+
+// findOneUser returns the first user by id that matches, or nil.
+func (s *Store) findOneUser(match func(models.UserModel) bool) (*models.UserModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range sortedIDs(s.users) {
+		if user := s.users[id]; match(user) {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Store) CreateUser(newUser *models.UserModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.Username == newUser.Username {
+			return ErrDuplicate
+		}
+	}
+	s.newRecord("users", &newUser.DefaultModel)
+	s.users[newUser.ID] = *newUser
+	return nil
+}
+
+func (s *Store) DeleteUnverifiedUsers(createdBefore time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := 0
+	for id, user := range s.users {
+		if !user.EMailVerified && user.CreatedAt.Before(createdBefore) {
+			delete(s.users, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *Store) UpdateUser(user *models.UserModel, updates map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.users[user.ID]
+	if !ok {
+		return nil
+	}
+	if err := applyUpdates(&stored, updates); err != nil {
+		return err
+	}
+	stored.UpdatedAt = time.Now()
+	s.users[user.ID] = stored
+	applyUpdates(user, updates)
+	user.UpdatedAt = stored.UpdatedAt
+	return nil
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/jatgam/wishlist-api/models"
+)
+
+func (s *Store) FindWishlist(listID int) (*models.WishlistModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list, ok := s.wishlists[listID]
+	if !ok {
+		return nil, nil
+	}
+	return &list, nil
+}
+
+func (s *Store) GetWishlists() (*[]models.WishlistModel, error) {
+	return s.getWishlists(func(list models.WishlistModel) bool { return true })
+}
+
+// GetSurpriseWishlists returns the owners wishlists that have surprise mode
+// on.
+func (s *Store) GetSurpriseWishlists(ownerID int) (*[]models.WishlistModel, error) {
+	return s.getWishlists(func(list models.WishlistModel) bool { return list.OwnerID == ownerID && list.SurpriseMode })
+}
+
+func (s *Store) getWishlists(match func(models.WishlistModel) bool) (*[]models.WishlistModel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lists := []models.WishlistModel{}
+	for _, id := range sortedIDs(s.wishlists) {
+		if list := s.wishlists[id]; match(list) {
+			lists = append(lists, list)
+		}
+	}
+	return &lists, nil
+}
+
+func (s *Store) CreateWishlist(newList *models.WishlistModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if newList.Visibility == "" {
+		newList.Visibility = models.WishlistVisibilityPublic
+	}
+	s.newRecord("wishlists", &newList.DefaultModel)
+	s.wishlists[newList.ID] = *newList
+	return nil
+}
+
+func (s *Store) UpdateWishlist(list *models.WishlistModel, updates map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.wishlists[list.ID]
+	if !ok {
+		return nil
+	}
+	if err := applyUpdates(&stored, updates); err != nil {
+		return err
+	}
+	stored.UpdatedAt = time.Now()
+	s.wishlists[list.ID] = stored
+	applyUpdates(list, updates)
+	list.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// DeleteWishlist removes a wishlist and soft deletes every item that belongs
+// to it.
+func (s *Store) DeleteWishlist(list *models.WishlistModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, item := range s.items {
+		if inWishlist(item, list.ID) && item.DeletedAt == nil {
+			item.DeletedAt = &now
+			s.items[id] = item
+		}
+	}
+	delete(s.wishlists, list.ID)
+	return nil
+}
@@ -1,9 +1,25 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
 
 type DefaultModel struct {
 	ID        int       `gorm:"column:id;type:integer;primary_key;unique;AUTO_INCREMENT"`
-	CreatedAt time.Time `gorm:"column:createdAt;type:DATETIME;not null"`
-	UpdatedAt time.Time `gorm:"column:updatedAt;type:DATETIME;not null"`
+	CreatedAt time.Time `gorm:"column:createdAt;not null"`
+	UpdatedAt time.Time `gorm:"column:updatedAt;not null"`
+}
+
+// quoted quotes the column names for the database dialect and joins them. The
+// camel case columns and rank can't be left bare in raw SQL, as postgres folds
+// names to lower case and rank is reserved in mysql.
+func quoted(db *gorm.DB, columns ...string) string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = db.Dialect().Quote(column)
+	}
+	return strings.Join(names, ", ")
 }
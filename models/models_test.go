@@ -0,0 +1,74 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+
+	"github.com/jatgam/wishlist-api/config"
+	"github.com/jatgam/wishlist-api/db"
+	"github.com/jatgam/wishlist-api/migrations"
+	"github.com/jatgam/wishlist-api/utils"
+)
+
+// newTestDB opens a fresh in memory SQLite database with every migration
+// applied. Setting TEST_DB_NAME runs against that database of TEST_DB_DRIVER
+// instead, rolling back every migration and applying them again, so use one
+// set aside for tests.
+func newTestDB(t *testing.T) *gorm.DB {
+	dbConf := &config.DBConfig{Driver: db.DriverSQLite, Database: ":memory:"}
+	if name := os.Getenv("TEST_DB_NAME"); name != "" {
+		dbConf = &config.DBConfig{
+			Driver:   utils.GetEnv("TEST_DB_DRIVER", db.DriverMySQL),
+			Hostname: utils.GetEnv("TEST_DB_HOSTNAME", "localhost"),
+			Database: name,
+			User:     utils.GetEnv("TEST_DB_USERNAME", "wishlist"),
+			Password: utils.GetEnv("TEST_DB_PASSWORD", "changeme"),
+			SSLMode:  utils.GetEnv("TEST_DB_SSLMODE", "disable"),
+		}
+	}
+	conn := db.Connect(dbConf)
+	conn.LogMode(false)
+	if err := resetTestDB(conn); err != nil {
+		conn.Close()
+		t.Fatalf("Migrating the test database failed: %s", err.Error())
+	}
+	return conn
+}
+
+func resetTestDB(conn *gorm.DB) error {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	entry := logrus.NewEntry(logger)
+	statuses, err := migrations.GetStatus(conn)
+	if err != nil {
+		return err
+	}
+	if _, err := migrations.Down(conn, len(statuses), entry); err != nil {
+		return err
+	}
+	_, err = migrations.Up(conn, entry)
+	return err
+}
+
+func createTestUser(t *testing.T, users UserRepository, username string) *UserModel {
+	user := &UserModel{Username: username, PasswordHash: "unused", EMail: username + "@example.com",
+		FirstName: username, LastName: "Test", UserLevel: 1, EMailVerified: true}
+	if err := users.CreateUser(user); err != nil {
+		t.Fatalf("Creating user %s failed: %s", username, err.Error())
+	}
+	return user
+}
+
+func createTestItem(t *testing.T, items ItemRepository, item *ItemModel) *ItemModel {
+	if item.URL == "" {
+		item.URL = "https://example.com/" + item.Name
+	}
+	if err := items.AddItem(item); err != nil {
+		t.Fatalf("Adding item %s failed: %s", item.Name, err.Error())
+	}
+	return item
+}
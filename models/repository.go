@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// UserRepository stores the users. Finds return nil when no user matches.
+type UserRepository interface {
+	FindUser(userID int) (*UserModel, error)
+	FindUserForAuth(userID int) (*UserModel, error)
+	FindUserByUsername(username string) (*UserModel, error)
+	FindUserByEMail(email string) (*UserModel, error)
+	FindUserByResetToken(token string) (*UserModel, error)
+	CreateUser(newUser *UserModel) error
+	// UpdateUser takes a map so fields can be set to their zero value or
+	// NULL. Keys are field or column names.
+	UpdateUser(user *UserModel, updates map[string]interface{}) error
+	DeleteUnverifiedUsers(createdBefore time.Time) (int, error)
+}
+
+// ItemRepository stores the items and their reservations. Finds return nil
+// when nothing matches, and soft deleted items are left out unless asked for.
+type ItemRepository interface {
+	FindItem(itemID int, withDeleted bool) (*ItemModel, error)
+	GetWantedItems(opts ItemListOptions) (*[]ItemModel, int, error)
+	GetAllItems(opts ItemListOptions) (*[]ItemModel, int, error)
+	GetReservedItems(userID int, opts ItemListOptions) (*[]ItemModel, int, error)
+	GetWishlistWantedItems(listID int, opts ItemListOptions) (*[]ItemModel, int, error)
+	GetWishlistAllItems(listID int, opts ItemListOptions) (*[]ItemModel, int, error)
+	GetWishlistActiveItems(listID int, opts ItemListOptions) (*[]ItemModel, int, error)
+	GetWishlistReservedItems(listID, userID int, opts ItemListOptions) (*[]ItemModel, int, error)
+	AddItem(newItem *ItemModel) error
+	AddItems(newItems []ItemModel) error
+	UpdateItem(item *ItemModel, updates map[string]interface{}) error
+	UpdateItemStatus(item *ItemModel, from, to string) (bool, error)
+	UpdateItemQuantity(item *ItemModel, quantity int, updates map[string]interface{}) (bool, error)
+	DeleteItem(item *ItemModel) error
+	RestoreItem(item *ItemModel) error
+
+	FindReservation(itemID, userID int) (*ReservationModel, error)
+	GetItemReservations(itemID int) (*[]ReservationModel, error)
+	GetUserReservations(userID int) (*[]ReservationModel, error)
+	ReserveItem(item *ItemModel, userID, quantity int) (bool, error)
+	UnReserveItem(item *ItemModel, reservation *ReservationModel) (bool, error)
+}
+
+// WishlistRepository stores the wishlists. Finds return nil when no wishlist
+// matches.
+type WishlistRepository interface {
+	FindWishlist(listID int) (*WishlistModel, error)
+	GetWishlists() (*[]WishlistModel, error)
+	GetSurpriseWishlists(ownerID int) (*[]WishlistModel, error)
+	CreateWishlist(newList *WishlistModel) error
+	UpdateWishlist(list *WishlistModel, updates map[string]interface{}) error
+	DeleteWishlist(list *WishlistModel) error
+}
+
+// TagRepository stores the tags and which items they are on. Finds return nil
+// when no tag matches.
+type TagRepository interface {
+	FindTag(tagID int) (*TagModel, error)
+	FindTagByName(ownerID int, name string) (*TagModel, error)
+	GetTags(ownerID int) (*[]TagModel, error)
+	CreateTag(newTag *TagModel) error
+	UpdateTag(tag *TagModel, updates map[string]interface{}) error
+	DeleteTag(tag *TagModel) error
+	TagItem(item *ItemModel, tag *TagModel) error
+	UntagItem(item *ItemModel, tag *TagModel) error
+}
+
+// InviteRepository stores the registration invites. Finds return nil when no
+// invite matches.
+type InviteRepository interface {
+	FindInvite(inviteID int) (*InviteModel, error)
+	GetInvites() (*[]InviteModel, error)
+	CreateInvite(newInvite *InviteModel) error
+	DeleteInvite(invite *InviteModel) error
+	UseInvite(code string, now time.Time) (bool, error)
+	// ReleaseInvite gives back a use of the code, for when the registration
+	// it was used for fails.
+	ReleaseInvite(code string) error
+}
@@ -0,0 +1,163 @@
+package models
+
+import "github.com/jinzhu/gorm"
+
+// ReservationModel is the db structure for a users claim on some quantity of
+// an item.
+type ReservationModel struct {
+	DefaultModel
+	ItemID   int `gorm:"column:itemid;type:integer;not null;unique_index:idx_reservation_item_user"`
+	UserID   int `gorm:"column:userid;type:integer;not null;unique_index:idx_reservation_item_user"`
+	Quantity int `gorm:"column:quantity;type:integer;not null"`
+}
+
+func (ReservationModel) TableName() string {
+	return "reservations1"
+}
+
+func (r *itemRepository) GetItemReservations(itemID int) (*[]ReservationModel, error) {
+	return r.getReservations(map[string]interface{}{"itemid": itemID})
+}
+
+func (r *itemRepository) GetUserReservations(userID int) (*[]ReservationModel, error) {
+	return r.getReservations(map[string]interface{}{"userid": userID})
+}
+
+func (r *itemRepository) getReservations(condition interface{}) (*[]ReservationModel, error) {
+	var model []ReservationModel
+	err := r.db.Where(condition).Find(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &model, err
+}
+
+// FindReservation will search for the users reservation of the item. Will
+// mask Record Not Found Errors.
+func (r *itemRepository) FindReservation(itemID, userID int) (*ReservationModel, error) {
+	var model ReservationModel
+	err := r.db.Where(map[string]interface{}{"itemid": itemID, "userid": userID}).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &model, err
+}
+
+// ReserveItem adds quantity to the users reservation of the item and updates
+// the items reserved totals. The items reserved quantity is only increased if
+// enough of the item remains, so concurrent reservations can't over claim it.
+// Returns false if the item no longer has enough remaining.
+func (r *itemRepository) ReserveItem(item *ItemModel, userID, quantity int) (bool, error) {
+	tx := r.db.Begin()
+	// Claiming the quantity first also locks the item row until the
+	// transaction ends, serializing reservations of the same item.
+	claim := tx.Model(&ItemModel{}).Where("id = ? AND status = ? AND reservedqty + ? <= quantity", item.ID, ItemStatusWanted, quantity).
+		UpdateColumn("reservedqty", gorm.Expr("reservedqty + ?", quantity))
+	if claim.Error != nil {
+		tx.Rollback()
+		return false, claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+	if err := setItemReservedFlag(tx, item.ID); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	var reservation ReservationModel
+	err := tx.Where(map[string]interface{}{"itemid": item.ID, "userid": userID}).First(&reservation).Error
+	if gorm.IsRecordNotFoundError(err) {
+		reservation = ReservationModel{ItemID: item.ID, UserID: userID, Quantity: quantity}
+		err = tx.Create(&reservation).Error
+	} else if err == nil {
+		err = tx.Model(&reservation).Update("quantity", gorm.Expr("quantity + ?", quantity)).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	return true, tx.Commit().Error
+}
+
+// UpdateItemQuantity applies the updates along with the new quantity, as long
+// as the quantity still covers what is reserved, and moves the items reserved
+// totals to match. Returns false if more of the item has been reserved since.
+func (r *itemRepository) UpdateItemQuantity(item *ItemModel, quantity int, updates map[string]interface{}) (bool, error) {
+	withQuantity := map[string]interface{}{"quantity": quantity}
+	for key, value := range updates {
+		withQuantity[key] = value
+	}
+	tx := r.db.Begin()
+	update := tx.Model(item).Where("reservedqty <= ?", quantity).Updates(withQuantity)
+	if update.Error != nil {
+		tx.Rollback()
+		return false, update.Error
+	}
+	if update.RowsAffected == 0 {
+		// Nothing is written when the values are unchanged, so check the
+		// reservations still fit before calling it a conflict.
+		var overReserved int
+		err := tx.Model(&ItemModel{}).Where("id = ? AND reservedqty > ?", item.ID, quantity).Count(&overReserved).Error
+		if err != nil || overReserved > 0 {
+			tx.Rollback()
+			return false, err
+		}
+	}
+	if err := setItemReservedFlag(tx, item.ID); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	return true, tx.Commit().Error
+}
+
+// UnReserveItem removes the reservation and releases its quantity back to the
+// item. Returns false if the reservation was changed or removed since it was
+// read, or the item has moved past reserved. Deleted items are released
+// whatever their status, so they come back with only the reservations that
+// remain if they are restored.
+func (r *itemRepository) UnReserveItem(item *ItemModel, reservation *ReservationModel) (bool, error) {
+	tx := r.db.Begin()
+	release := tx.Where("id = ? AND quantity = ?", reservation.ID, reservation.Quantity).Delete(&ReservationModel{})
+	if release.Error != nil {
+		tx.Rollback()
+		return false, release.Error
+	}
+	if release.RowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+	unclaim := tx.Unscoped().Model(&ItemModel{}).
+		Where("id = ? AND (status IN (?) OR "+quoted(tx, "deletedAt")+" IS NOT NULL) AND reservedqty >= ?",
+			item.ID, []string{ItemStatusWanted, ItemStatusReserved}, reservation.Quantity).
+		UpdateColumn("reservedqty", gorm.Expr("reservedqty - ?", reservation.Quantity))
+	if unclaim.Error != nil {
+		tx.Rollback()
+		return false, unclaim.Error
+	}
+	if unclaim.RowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+	if err := setItemReservedFlag(tx, item.ID); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	return true, tx.Commit().Error
+}
+
+// setItemReservedFlag marks the item reserved once its full quantity is
+// claimed, and moves its status between wanted and reserved to match. It is a
+// separate statement since databases disagree on whether assignments in one
+// UPDATE see each others new values. The items updatedAt is left alone so it
+// doesn't hint at reservations on surprise wishlists.
+func setItemReservedFlag(tx *gorm.DB, itemID int) error {
+	err := tx.Unscoped().Model(&ItemModel{}).Where("id = ?", itemID).
+		UpdateColumn("reserved", gorm.Expr("reservedqty >= quantity")).Error
+	if err != nil {
+		return err
+	}
+	return tx.Unscoped().Model(&ItemModel{}).Where("id = ? AND status IN (?)", itemID, []string{ItemStatusWanted, ItemStatusReserved}).
+		UpdateColumn("status", gorm.Expr("CASE WHEN reservedqty >= quantity THEN ? ELSE ? END", ItemStatusReserved, ItemStatusWanted)).Error
+}
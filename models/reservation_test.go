@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestReserveItemConcurrently reserves with the item as every caller first
+// read it, the same as concurrent requests that all saw it wanted. SQLite
+// has a single connection, so there the reservers only race on the stale
+// item. Set TEST_DB_NAME to race their queries on MySQL or Postgres too.
+func TestReserveItemConcurrently(t *testing.T) {
+	conn := newTestDB(t)
+	defer conn.Close()
+	users, items := NewUserRepository(conn), NewItemRepository(conn)
+
+	owner := createTestUser(t, users, "owner")
+	const quantity, reservers = 3, 10
+	item := createTestItem(t, items, &ItemModel{Name: "Socks", OwnerID: &owner.ID, Quantity: quantity})
+	stale, err := items.FindItem(item.ID, false)
+	if err != nil {
+		t.Fatalf("Finding the item failed: %s", err.Error())
+	}
+
+	results := make([]bool, reservers)
+	errs := make([]error, reservers)
+	var wg sync.WaitGroup
+	for i := 0; i < reservers; i++ {
+		user := createTestUser(t, users, fmt.Sprintf("reserver%d", i))
+		wg.Add(1)
+		go func(i, userID int) {
+			defer wg.Done()
+			results[i], errs[i] = items.ReserveItem(stale, userID, 1)
+		}(i, user.ID)
+	}
+	wg.Wait()
+
+	reserved := 0
+	for i := range results {
+		if errs[i] != nil {
+			t.Errorf("ReserveItem failed: %s", errs[i].Error())
+		}
+		if results[i] {
+			reserved++
+		}
+	}
+	if reserved != quantity {
+		t.Errorf("%v reservations succeeded, want %v", reserved, quantity)
+	}
+	found, err := items.FindItem(item.ID, false)
+	if err != nil {
+		t.Fatalf("Finding the item failed: %s", err.Error())
+	}
+	if found.ReservedQuantity != quantity || !found.Reserved || found.Status != ItemStatusReserved {
+		t.Errorf("Item has reservedqty %v, reserved %v, status %v, want %v, true, %v",
+			found.ReservedQuantity, found.Reserved, found.Status, quantity, ItemStatusReserved)
+	}
+}
+
+// TestUpdateItemQuantity changes the quantity with the item as it was read
+// before a reservation, like an owner editing while someone reserves.
+func TestUpdateItemQuantity(t *testing.T) {
+	conn := newTestDB(t)
+	defer conn.Close()
+	users, items := NewUserRepository(conn), NewItemRepository(conn)
+
+	owner := createTestUser(t, users, "owner")
+	reserver := createTestUser(t, users, "reserver")
+	item := createTestItem(t, items, &ItemModel{Name: "Socks", OwnerID: &owner.ID, Quantity: 3})
+	stale, err := items.FindItem(item.ID, false)
+	if err != nil {
+		t.Fatalf("Finding the item failed: %s", err.Error())
+	}
+	if ok, err := items.ReserveItem(item, reserver.ID, 2); !ok || err != nil {
+		t.Fatalf("ReserveItem returned %v, %v", ok, err)
+	}
+
+	tests := []struct {
+		quantity int
+		want     bool
+		status   string
+	}{
+		{1, false, ItemStatusWanted},
+		{2, true, ItemStatusReserved},
+		{2, true, ItemStatusReserved},
+		{4, true, ItemStatusWanted},
+	}
+	for _, test := range tests {
+		updated, err := items.UpdateItemQuantity(stale, test.quantity, map[string]interface{}{"notes": "wool"})
+		if err != nil || updated != test.want {
+			t.Errorf("UpdateItemQuantity(%v) returned %v, %v, want %v", test.quantity, updated, err, test.want)
+		}
+		found, err := items.FindItem(item.ID, false)
+		if err != nil {
+			t.Fatalf("Finding the item failed: %s", err.Error())
+		}
+		if found.ReservedQuantity != 2 || found.Status != test.status || found.Reserved != (test.status == ItemStatusReserved) {
+			t.Errorf("After UpdateItemQuantity(%v) item has quantity %v, reservedqty %v, reserved %v, status %v",
+				test.quantity, found.Quantity, found.ReservedQuantity, found.Reserved, found.Status)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// itemTagsTable joins items to their tags.
+const itemTagsTable = "itemtags1"
+
+// TagModel is the db structure for a users label that groups their items, like
+// books or kitchen.
+type TagModel struct {
+	DefaultModel
+	Owner   UserModel `gorm:"foreignkey:OwnerID"`
+	OwnerID int       `gorm:"column:ownerid;type:integer;not null;unique_index:idx_tag_owner_name"`
+	Name    string    `gorm:"column:name;type:varchar(64);not null;unique_index:idx_tag_owner_name"`
+}
+
+func (TagModel) TableName() string {
+	return "tags1"
+}
+
+func TagDefaultScope(db *gorm.DB) *gorm.DB {
+	return db.Select(quoted(db, "id", "ownerid", "name", "createdAt", "updatedAt"))
+}
+
+func TagOrderScope(db *gorm.DB) *gorm.DB {
+	return db.Order("name ASC")
+}
+
+type tagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository creates a TagRepository stored in the database.
+func NewTagRepository(db *gorm.DB) TagRepository {
+	return &tagRepository{db: db}
+}
+
+func (r *tagRepository) FindTag(tagID int) (*TagModel, error) {
+	return r.findOneTag(map[string]interface{}{"id": tagID})
+}
+
+func (r *tagRepository) FindTagByName(ownerID int, name string) (*TagModel, error) {
+	return r.findOneTag(map[string]interface{}{"ownerid": ownerID, "name": name})
+}
+
+// GetTags returns the owners tags in name order.
+func (r *tagRepository) GetTags(ownerID int) (*[]TagModel, error) {
+	var model []TagModel
+	err := r.db.Scopes(TagDefaultScope, TagOrderScope).Where(map[string]interface{}{"ownerid": ownerID}).Find(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &model, err
+}
+
+// findOneTag will search for a tag that matches the supplied condition. Will
+// mask Record Not Found Errors.
+func (r *tagRepository) findOneTag(condition interface{}) (*TagModel, error) {
+	var model TagModel
+	err := r.db.Scopes(TagDefaultScope).Where(condition).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &model, err
+}
+
+func (r *tagRepository) CreateTag(newTag *TagModel) error {
+	err := r.db.Create(newTag).Error
+	return err
+}
+
+func (r *tagRepository) UpdateTag(tag *TagModel, updates map[string]interface{}) error {
+	err := r.db.Model(tag).Updates(updates).Error
+	return err
+}
+
+// DeleteTag removes the tag from every item it was on, then the tag.
+func (r *tagRepository) DeleteTag(tag *TagModel) error {
+	tx := r.db.Begin()
+	if err := tx.Exec("DELETE FROM "+itemTagsTable+" WHERE tagid = ?", tag.ID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(tag).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// TagItem adds the tag to the item. Tagging an item twice is a no-op.
+func (r *tagRepository) TagItem(item *ItemModel, tag *TagModel) error {
+	err := r.db.Model(item).Association("Tags").Append(tag).Error
+	return err
+}
+
+func (r *tagRepository) UntagItem(item *ItemModel, tag *TagModel) error {
+	err := r.db.Model(item).Association("Tags").Delete(tag).Error
+	return err
+}
+
+// ItemTagsScope loads the tags of the items.
+func ItemTagsScope(db *gorm.DB) *gorm.DB {
+	return db.Preload("Tags", TagOrderScope)
+}
+
+// taggedScope limits items to those with a tag of the given name, ignoring
+// case.
+func taggedScope(name string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		taggedIDs := db.New().Table(itemTagsTable).Select(itemTagsTable+".itemid").
+			Joins("JOIN tags1 ON tags1.id = "+itemTagsTable+".tagid").
+			Where("LOWER(tags1.name) = ?", strings.ToLower(name))
+		// SubQuery adds its own parentheses, see reservedByScope.
+		return db.Where("id IN ?", taggedIDs.SubQuery())
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import "testing"
+
+func TestTagRepository(t *testing.T) {
+	conn := newTestDB(t)
+	defer conn.Close()
+	users, items, tags := NewUserRepository(conn), NewItemRepository(conn), NewTagRepository(conn)
+	owner := createTestUser(t, users, "owner")
+
+	books := &TagModel{OwnerID: owner.ID, Name: "Books"}
+	if err := tags.CreateTag(books); err != nil {
+		t.Fatalf("CreateTag failed: %s", err.Error())
+	}
+	if err := tags.CreateTag(&TagModel{OwnerID: owner.ID, Name: "Books"}); err == nil {
+		t.Error("Creating a tag with a taken name didn't fail")
+	}
+	if found, err := tags.FindTagByName(owner.ID, "Books"); err != nil || found == nil || found.ID != books.ID {
+		t.Errorf("FindTagByName returned %v, %v, want Books", found, err)
+	}
+
+	novel := createTestItem(t, items, &ItemModel{Name: "Novel", OwnerID: &owner.ID})
+	createTestItem(t, items, &ItemModel{Name: "Scarf", OwnerID: &owner.ID})
+	atlas := createTestItem(t, items, &ItemModel{Name: "Atlas", OwnerID: &owner.ID})
+	for _, item := range []*ItemModel{novel, atlas, atlas} {
+		if err := tags.TagItem(item, books); err != nil {
+			t.Fatalf("TagItem failed: %s", err.Error())
+		}
+	}
+
+	tagged, total, err := items.GetAllItems(ItemListOptions{Tag: "books"})
+	if err != nil || testItemNames(tagged) != "[Atlas Novel]" || total != 2 {
+		t.Errorf("Tag filter returned %v of %v, %v, want [Atlas Novel] of 2", testItemNames(tagged), total, err)
+	}
+	if tags := (*tagged)[0].Tags; len(tags) != 1 || tags[0].Name != "Books" {
+		t.Errorf("Atlas has tags %v, want only Books", tags)
+	}
+
+	if err := tags.UntagItem(novel, books); err != nil {
+		t.Fatalf("UntagItem failed: %s", err.Error())
+	}
+	if tagged, _, _ := items.GetAllItems(ItemListOptions{Tag: "Books"}); testItemNames(tagged) != "[Atlas]" {
+		t.Errorf("Tag filter after untagging returned %v, want [Atlas]", testItemNames(tagged))
+	}
+	if err := tags.DeleteTag(books); err != nil {
+		t.Fatalf("DeleteTag failed: %s", err.Error())
+	}
+	if found, _ := items.FindItem(atlas.ID, false); found == nil {
+		t.Error("Deleting a tag deleted its item")
+	}
+	if all, _, _ := items.GetAllItems(ItemListOptions{}); len((*all)[0].Tags) != 0 {
+		t.Errorf("Atlas still has tags %v after the tag was deleted", (*all)[0].Tags)
+	}
+}
@@ -5,23 +5,32 @@ import (
 
 	"github.com/jinzhu/gorm"
 
-	"github.com/jatgam/wishlist-api/db"
 	"github.com/jatgam/wishlist-api/utils"
 )
 
 // UserModel is the db structure for users
 type UserModel struct {
 	DefaultModel
-	Username             string      `gorm:"column:username;type:varchar(255);unique;not null"`
-	PasswordHash         string      `gorm:"column:hash;type:varchar(255);not null"`
-	PasswordReset        bool        `gorm:"column:passwordreset;type:tinyint(1);not null;DEFAULT:false"`
-	PasswordResetToken   *string     `gorm:"column:passwordResetToken;type:varchar(255);DEFAULT:NULL"`
-	PasswordResetExpires *time.Time  `gorm:"column:passwordResetExpires;type:DATETIME;DEFAULT:NULL"`
-	UserLevel            uint        `gorm:"column:userlevel;type:tinyint unsigned;not null"`
-	EMail                string      `gorm:"column:email;type:varchar(255);not null"`
-	FirstName            string      `gorm:"column:firstname;type:varchar(255);not null"`
-	LastName             string      `gorm:"column:lastname;type:varchar(255);not null"`
-	ReservedItems        []ItemModel `gorm:"foreignkey:ReserverID"`
+	Username             string     `gorm:"column:username;type:varchar(255);unique;not null"`
+	PasswordHash         string     `gorm:"column:hash;type:varchar(255);not null"`
+	PasswordReset        bool       `gorm:"column:passwordreset;type:boolean;not null;DEFAULT:false"`
+	PasswordResetToken   *string    `gorm:"column:passwordResetToken;type:varchar(255);DEFAULT:NULL"`
+	PasswordResetExpires *time.Time `gorm:"column:passwordResetExpires;DEFAULT:NULL"`
+	UserLevel            uint       `gorm:"column:userlevel;type:smallint;not null"`
+	EMail                string     `gorm:"column:email;type:varchar(255);not null"`
+	FirstName            string     `gorm:"column:firstname;type:varchar(255);not null"`
+	LastName             string     `gorm:"column:lastname;type:varchar(255);not null"`
+	// PendingEMail is a new address waiting to replace EMail, once the link
+	// mailed to it is followed.
+	PendingEMail *string `gorm:"column:pendingEmail;type:varchar(255);DEFAULT:NULL"`
+	// TokenVersion goes up on every password change. Tokens carry the
+	// version they were issued at, and older ones are refused.
+	TokenVersion int `gorm:"column:tokenVersion;type:integer;not null;DEFAULT:0"`
+	// EMailVerified is set once the user follows the link mailed when they
+	// registered. Until then they can't log in.
+	EMailVerified     bool               `gorm:"column:emailVerified;type:boolean;not null;DEFAULT:false"`
+	EMailVerifySentAt *time.Time         `gorm:"column:emailVerifySentAt;DEFAULT:NULL"`
+	Reservations      []ReservationModel `gorm:"foreignkey:UserID"`
 }
 
 func (UserModel) TableName() string {
@@ -29,11 +38,11 @@ func (UserModel) TableName() string {
 }
 
 func UserDefaultScope(db *gorm.DB) *gorm.DB {
-	return db.Select("id, username, userlevel, email, firstname, lastname, createdAt, updatedAt")
+	return db.Select(quoted(db, "id", "username", "userlevel", "email", "pendingEmail", "firstname", "lastname", "tokenVersion", "emailVerified", "createdAt", "updatedAt"))
 }
 
 func UserPassResetScope(db *gorm.DB) *gorm.DB {
-	return db.Select("id, username, passwordreset, passwordResetToken, passwordResetExpires, userlevel, email, firstname, lastname, createdAt, updatedAt")
+	return db.Select(quoted(db, "id", "username", "passwordreset", "passwordResetToken", "passwordResetExpires", "userlevel", "email", "firstname", "lastname", "createdAt", "updatedAt"))
 }
 
 func UserAuthScope(db *gorm.DB) *gorm.DB {
@@ -61,37 +70,65 @@ func (u *UserModel) ValidatePassword(password string) bool {
 	return true
 }
 
-// FindOneUser will search for a user that matches the supplied condition.
+type userRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository creates a UserRepository stored in the database.
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) FindUser(userID int) (*UserModel, error) {
+	return r.findOneUser(map[string]interface{}{"id": userID}, UserDefaultScope)
+}
+
+// FindUserForAuth returns the user with the password hash, for checking
+// their password.
+func (r *userRepository) FindUserForAuth(userID int) (*UserModel, error) {
+	return r.findOneUser(map[string]interface{}{"id": userID}, UserAuthScope)
+}
+
+func (r *userRepository) FindUserByUsername(username string) (*UserModel, error) {
+	return r.findOneUser(map[string]interface{}{"username": username}, UserAuthScope)
+}
+
+func (r *userRepository) FindUserByEMail(email string) (*UserModel, error) {
+	return r.findOneUser(map[string]interface{}{"email": email}, UserAuthScope)
+}
+
+func (r *userRepository) FindUserByResetToken(token string) (*UserModel, error) {
+	return r.findOneUser(map[string]interface{}{"passwordResetToken": token}, UserPassResetScope)
+}
+
+// findOneUser will search for a user that matches the supplied condition.
 // Will mask Record Not Found Errors.
-func FindOneUser(condition interface{}, scopes ...func(*gorm.DB) *gorm.DB) (*UserModel, error) {
-	db := db.GetDB()
+func (r *userRepository) findOneUser(condition interface{}, scopes ...func(*gorm.DB) *gorm.DB) (*UserModel, error) {
 	if len(scopes) < 1 {
 		scopes = append(scopes, UserDefaultScope)
 	}
 	var model UserModel
-	err := db.Scopes(scopes...).Where(condition).First(&model).Error
+	err := r.db.Scopes(scopes...).Where(condition).First(&model).Error
 	if gorm.IsRecordNotFoundError(err) {
 		return nil, nil
 	}
 	return &model, err
 }
 
-func CreateUser(newUser *UserModel) error {
-	db := db.GetDB()
-	err := db.Create(newUser).Error
+func (r *userRepository) CreateUser(newUser *UserModel) error {
+	err := r.db.Create(newUser).Error
 	return err
 }
 
-func UpdateUser(user *UserModel, updates UserModel) error {
-	db := db.GetDB()
-	err := db.Model(user).Updates(updates).Error
+func (r *userRepository) UpdateUser(user *UserModel, updates map[string]interface{}) error {
+	err := r.db.Model(user).Updates(updates).Error
 	return err
 }
 
-// UpdateUserWithMap will update an existing user. A map is needed instead
-// of the UserModel when you want/need to insert NULL sql field values.
-func UpdateUserWithMap(user *UserModel, updates map[string]interface{}) error {
-	db := db.GetDB()
-	err := db.Model(user).Updates(updates).Error
-	return err
+// DeleteUnverifiedUsers removes the users created before the time that never
+// verified their email address, and returns how many were removed.
+func (r *userRepository) DeleteUnverifiedUsers(createdBefore time.Time) (int, error) {
+	condition := quoted(r.db, "emailVerified") + " = ? AND " + quoted(r.db, "createdAt") + " < ?"
+	deleted := r.db.Where(condition, false, createdBefore).Delete(&UserModel{})
+	return int(deleted.RowsAffected), deleted.Error
 }
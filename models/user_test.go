@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserRepository(t *testing.T) {
+	conn := newTestDB(t)
+	defer conn.Close()
+	users := NewUserRepository(conn)
+	created := createTestUser(t, users, "alice")
+
+	found, err := users.FindUser(created.ID)
+	if err != nil || found == nil {
+		t.Fatalf("FindUser returned %v, %v", found, err)
+	}
+	if found.Username != "alice" || found.PasswordHash != "" {
+		t.Errorf("FindUser returned username %q and hash %q, want alice without the hash", found.Username, found.PasswordHash)
+	}
+	if found, err := users.FindUserByUsername("alice"); err != nil || found == nil || found.PasswordHash != "unused" {
+		t.Errorf("FindUserByUsername returned %v, %v, want alice with the hash", found, err)
+	}
+	if found, err := users.FindUserByEMail("alice@example.com"); err != nil || found == nil || found.ID != created.ID {
+		t.Errorf("FindUserByEMail returned %v, %v, want alice", found, err)
+	}
+	if found, err := users.FindUserByUsername("bob"); err != nil || found != nil {
+		t.Errorf("FindUserByUsername of a missing user returned %v, %v, want nil, nil", found, err)
+	}
+
+	token := "reset-token"
+	if err := users.UpdateUser(created, map[string]interface{}{"PasswordResetToken": token, "TokenVersion": 2}); err != nil {
+		t.Fatalf("UpdateUser failed: %s", err.Error())
+	}
+	if found, err := users.FindUserByResetToken(token); err != nil || found == nil || found.ID != created.ID {
+		t.Errorf("FindUserByResetToken returned %v, %v, want alice", found, err)
+	}
+	if found, _ := users.FindUserForAuth(created.ID); found == nil || found.TokenVersion != 2 {
+		t.Errorf("FindUserForAuth returned %v, want token version 2", found)
+	}
+	if err := users.UpdateUser(created, map[string]interface{}{"PasswordResetToken": nil}); err != nil {
+		t.Fatalf("UpdateUser failed: %s", err.Error())
+	}
+	if found, err := users.FindUserByResetToken(token); err != nil || found != nil {
+		t.Errorf("FindUserByResetToken of a cleared token returned %v, %v, want nil, nil", found, err)
+	}
+}
+
+func TestDeleteUnverifiedUsers(t *testing.T) {
+	conn := newTestDB(t)
+	defer conn.Close()
+	users := NewUserRepository(conn)
+	cutoff := time.Now().Add(-time.Hour)
+	old := cutoff.Add(-time.Hour)
+
+	for _, user := range []*UserModel{
+		{DefaultModel: DefaultModel{CreatedAt: old}, Username: "verified", EMailVerified: true},
+		{DefaultModel: DefaultModel{CreatedAt: old}, Username: "expired"},
+		{Username: "pending"},
+	} {
+		user.PasswordHash, user.EMail, user.FirstName, user.LastName = "unused", user.Username+"@example.com", user.Username, "Test"
+		if err := users.CreateUser(user); err != nil {
+			t.Fatalf("Creating user %s failed: %s", user.Username, err.Error())
+		}
+	}
+
+	deleted, err := users.DeleteUnverifiedUsers(cutoff)
+	if err != nil || deleted != 1 {
+		t.Fatalf("DeleteUnverifiedUsers deleted %v with error %v, want 1", deleted, err)
+	}
+	for username, want := range map[string]bool{"verified": true, "expired": false, "pending": true} {
+		if found, _ := users.FindUserByUsername(username); (found != nil) != want {
+			t.Errorf("User %s exists is %v, want %v", username, found != nil, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Wishlist visibility levels. Public lists can be viewed by anyone, registered
+// lists require a logged in user, and private lists are only visible to the
+// owner and admins.
+const (
+	WishlistVisibilityPublic     = "public"
+	WishlistVisibilityRegistered = "registered"
+	WishlistVisibilityPrivate    = "private"
+)
+
+// WishlistModel is the db structure for a named list of items owned by a user
+type WishlistModel struct {
+	DefaultModel
+	Owner        UserModel  `gorm:"foreignkey:OwnerID"`
+	OwnerID      int        `gorm:"column:ownerid;type:integer;not null"`
+	Title        string     `gorm:"column:title;type:varchar(255);not null"`
+	Description  string     `gorm:"column:description;type:text"`
+	Occasion     string     `gorm:"column:occasion;type:varchar(255)"`
+	OccasionDate *time.Time `gorm:"column:occasionDate;DEFAULT:NULL"`
+	Visibility   string     `gorm:"column:visibility;type:varchar(16);not null;DEFAULT:'public'"`
+	// SurpriseMode hides the reservation state of the lists items from the
+	// owner until the occasion date has passed.
+	SurpriseMode bool        `gorm:"column:surprisemode;type:boolean;not null;DEFAULT:false"`
+	Items        []ItemModel `gorm:"foreignkey:WishlistID"`
+}
+
+func (WishlistModel) TableName() string {
+	return "wishlists1"
+}
+
+func WishlistDefaultScope(db *gorm.DB) *gorm.DB {
+	return db.Select(quoted(db, "id", "ownerid", "title", "description", "occasion", "occasionDate", "visibility", "surprisemode", "createdAt", "updatedAt"))
+}
+
+func WishlistOrderScope(db *gorm.DB) *gorm.DB {
+	return db.Order("id ASC")
+}
+
+type wishlistRepository struct {
+	db *gorm.DB
+}
+
+// NewWishlistRepository creates a WishlistRepository stored in the database.
+func NewWishlistRepository(db *gorm.DB) WishlistRepository {
+	return &wishlistRepository{db: db}
+}
+
+func (r *wishlistRepository) FindWishlist(listID int) (*WishlistModel, error) {
+	return r.findOneWishlist(map[string]interface{}{"id": listID})
+}
+
+func (r *wishlistRepository) GetWishlists() (*[]WishlistModel, error) {
+	return r.getWishlists(map[string]interface{}{}, WishlistDefaultScope, WishlistOrderScope)
+}
+
+// GetSurpriseWishlists returns the owners wishlists that have surprise mode
+// on.
+func (r *wishlistRepository) GetSurpriseWishlists(ownerID int) (*[]WishlistModel, error) {
+	return r.getWishlists(map[string]interface{}{"ownerid": ownerID, "surprisemode": true})
+}
+
+func (r *wishlistRepository) getWishlists(condition interface{}, scopes ...func(*gorm.DB) *gorm.DB) (*[]WishlistModel, error) {
+	if len(scopes) < 1 {
+		scopes = append(scopes, WishlistDefaultScope)
+	}
+	var model []WishlistModel
+	err := r.db.Scopes(scopes...).Where(condition).Find(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &model, err
+}
+
+// findOneWishlist will search for a wishlist that matches the supplied
+// condition. Will mask Record Not Found Errors.
+func (r *wishlistRepository) findOneWishlist(condition interface{}, scopes ...func(*gorm.DB) *gorm.DB) (*WishlistModel, error) {
+	if len(scopes) < 1 {
+		scopes = append(scopes, WishlistDefaultScope)
+	}
+	var model WishlistModel
+	err := r.db.Scopes(scopes...).Where(condition).First(&model).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	return &model, err
+}
+
+func (r *wishlistRepository) CreateWishlist(newList *WishlistModel) error {
+	err := r.db.Create(newList).Error
+	return err
+}
+
+func (r *wishlistRepository) UpdateWishlist(list *WishlistModel, updates map[string]interface{}) error {
+	err := r.db.Model(list).Updates(updates).Error
+	return err
+}
+
+// DeleteWishlist removes a wishlist and every item that belongs to it.
+func (r *wishlistRepository) DeleteWishlist(list *WishlistModel) error {
+	tx := r.db.Begin()
+	if err := tx.Where(map[string]interface{}{"wishlistid": list.ID}).Delete(ItemModel{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Delete(list).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestWishlistRepository(t *testing.T) {
+	conn := newTestDB(t)
+	defer conn.Close()
+	users, items, lists := NewUserRepository(conn), NewItemRepository(conn), NewWishlistRepository(conn)
+	owner := createTestUser(t, users, "owner")
+	reserver := createTestUser(t, users, "reserver")
+
+	plain := &WishlistModel{OwnerID: owner.ID, Title: "Birthday"}
+	surprise := &WishlistModel{OwnerID: owner.ID, Title: "Wedding", SurpriseMode: true, Visibility: WishlistVisibilityPrivate}
+	for _, list := range []*WishlistModel{plain, surprise} {
+		if err := lists.CreateWishlist(list); err != nil {
+			t.Fatalf("Creating wishlist %s failed: %s", list.Title, err.Error())
+		}
+	}
+	if found, err := lists.FindWishlist(plain.ID); err != nil || found == nil || found.Visibility != WishlistVisibilityPublic {
+		t.Errorf("FindWishlist returned %v, %v, want a public list", found, err)
+	}
+	if found, err := lists.GetSurpriseWishlists(owner.ID); err != nil || len(*found) != 1 || (*found)[0].ID != surprise.ID {
+		t.Errorf("GetSurpriseWishlists returned %v, %v, want only %s", found, err, surprise.Title)
+	}
+	if err := lists.UpdateWishlist(plain, map[string]interface{}{"title": "Party"}); err != nil {
+		t.Fatalf("UpdateWishlist failed: %s", err.Error())
+	}
+	if found, _ := lists.GetWishlists(); len(*found) != 2 || (*found)[0].Title != "Party" {
+		t.Errorf("GetWishlists returned %v, want Party then Wedding", found)
+	}
+
+	item := createTestItem(t, items, &ItemModel{Name: "Socks", OwnerID: &owner.ID, WishlistID: &plain.ID})
+	if reserved, err := items.ReserveItem(item, reserver.ID, 1); err != nil || !reserved {
+		t.Fatalf("Reserving returned %v, %v", reserved, err)
+	}
+	if err := lists.DeleteWishlist(plain); err != nil {
+		t.Fatalf("DeleteWishlist failed: %s", err.Error())
+	}
+	if found, err := lists.FindWishlist(plain.ID); err != nil || found != nil {
+		t.Errorf("FindWishlist of a deleted list returned %v, %v, want nil, nil", found, err)
+	}
+	if found, _ := items.FindItem(item.ID, false); found != nil {
+		t.Error("The item of a deleted wishlist wasn't deleted")
+	}
+	// The items are soft deleted, so reservers can still see them.
+	if reserved, _, err := items.GetReservedItems(reserver.ID, ItemListOptions{}); err != nil || len(*reserved) != 1 {
+		t.Errorf("GetReservedItems returned %v, %v, want the deleted item", reserved, err)
+	}
+}
@@ -7,13 +7,14 @@ import (
 	"github.com/gin-gonic/gin"
 
 	v1 "github.com/jatgam/wishlist-api/routes/v1"
+	"github.com/jatgam/wishlist-api/service"
 )
 
 func health(c *gin.Context) {
 	c.String(http.StatusOK, "OK")
 }
 
-func SetupRoutes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware) {
+func SetupRoutes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware, svc *service.Service) {
 	router.GET("/health", health)
-	v1.SetupV1Routes(router, ginjwt) // V1 Technically was never versioned, so all routes are at the root.
+	v1.SetupV1Routes(router, ginjwt, svc) // V1 Technically was never versioned, so all routes are at the root.
 }
@@ -0,0 +1,207 @@
+package v1
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/jatgam/wishlist-api/metrics"
+	"github.com/jatgam/wishlist-api/microservice"
+	"github.com/jatgam/wishlist-api/types"
+)
+
+type exportWishlistForm struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv json html"`
+}
+
+// exportDocument is the versioned JSON export. The item fields use the same
+// names as the add item form, so an export can be imported again.
+type exportDocument struct {
+	Version  int            `json:"version"`
+	Wishlist exportWishlist `json:"wishlist"`
+	Items    []exportItem   `json:"items"`
+}
+
+type exportWishlist struct {
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	Occasion     string     `json:"occasion"`
+	OccasionDate *time.Time `json:"occasiondate"`
+}
+
+type exportItem struct {
+	Name      string   `json:"name"`
+	URL       string   `json:"url"`
+	Rank      int      `json:"rank"`
+	Price     *float64 `json:"price"`
+	Currency  string   `json:"currency,omitempty"`
+	Quantity  int      `json:"quantity"`
+	Notes     string   `json:"notes,omitempty"`
+	ImageURL  string   `json:"imageurl,omitempty"`
+	Size      string   `json:"size,omitempty"`
+	Color     string   `json:"color,omitempty"`
+	Tags      []string `json:"tags"`
+	Status    string   `json:"status"`
+	Remaining *int     `json:"remaining,omitempty"`
+}
+
+var exportHTML = template.Must(template.New("wishlist").Funcs(template.FuncMap{
+	"price": exportPrice,
+	"date":  func(t *time.Time) string { return t.Format("January 2, 2006") },
+}).Parse(exportHTMLTemplate))
+
+func (a *api) exportWishlistItems(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	var listInfo wishlistURI
+	if err := c.ShouldBindUri(&listInfo); err != nil {
+		mylogger.Debug("Export Wishlist Data Validation Error")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	var exportInfo exportWishlistForm
+	if err := c.ShouldBindQuery(&exportInfo); err != nil {
+		mylogger.Debug("Export Wishlist Data Validation Error")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	viewerID, admin := getViewer(c)
+	export, err := a.svc.ExportWishlist(listInfo.ListID, viewerID, admin, mylogger)
+	if err != nil {
+		mylogger.Error("Failed to Export Wishlist")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListGetError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("wishlist-%v", listInfo.ListID)
+	switch exportInfo.Format {
+	case "csv":
+		body, err := exportCSV(export)
+		if err != nil {
+			mylogger.Errorf("Failed to write Wishlist csv: %s", err.Error())
+			metrics.WishlistErrors.WithLabelValues(metrics.ListGetError).Inc()
+			types.WriteResponse(c, http.StatusInternalServerError, "Failed to export the wishlist")
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", body)
+	case "html":
+		var body bytes.Buffer
+		if err := exportHTML.Execute(&body, export); err != nil {
+			mylogger.Errorf("Failed to render Wishlist html: %s", err.Error())
+			metrics.WishlistErrors.WithLabelValues(metrics.ListGetError).Inc()
+			types.WriteResponse(c, http.StatusInternalServerError, "Failed to export the wishlist")
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", body.Bytes())
+	default:
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		c.JSON(http.StatusOK, exportJSON(export))
+	}
+	mylogger.Info("Exported Wishlist")
+}
+
+func exportJSON(export *types.WishlistExport) exportDocument {
+	list := export.Wishlist
+	doc := exportDocument{Version: itemsDocumentVersion, Items: []exportItem{},
+		Wishlist: exportWishlist{Title: list.Title, Description: list.Description,
+			Occasion: list.Occasion, OccasionDate: list.OccasionDate}}
+	for _, item := range export.Items {
+		exported := exportItem{Name: item.Name, URL: item.Url, Rank: item.Rank, Price: item.Price,
+			Currency: item.Currency, Quantity: item.Quantity, Notes: item.Notes, ImageURL: item.ImageURL,
+			Size: item.Size, Color: item.Color, Tags: item.Tags, Status: item.Status}
+		if export.ShowReservations {
+			remaining := item.Remaining
+			exported.Remaining = &remaining
+		}
+		doc.Items = append(doc.Items, exported)
+	}
+	return doc
+}
+
+// exportCSV writes the items with a header row using the add item form field
+// names, so the file can be imported again. Tags are separated by semicolons.
+func exportCSV(export *types.WishlistExport) ([]byte, error) {
+	var body bytes.Buffer
+	writer := csv.NewWriter(&body)
+	header := []string{"name", "url", "rank", "price", "currency", "quantity", "notes", "imageurl", "size", "color", "tags", "status"}
+	if export.ShowReservations {
+		header = append(header, "remaining")
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	for _, item := range export.Items {
+		record := []string{item.Name, item.Url, strconv.Itoa(item.Rank), exportPrice(item.Price), item.Currency,
+			strconv.Itoa(item.Quantity), item.Notes, item.ImageURL, item.Size, item.Color,
+			strings.Join(item.Tags, ";"), item.Status}
+		if export.ShowReservations {
+			record = append(record, strconv.Itoa(item.Remaining))
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return body.Bytes(), writer.Error()
+}
+
+func exportPrice(price *float64) string {
+	if price == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*price, 'f', 2, 64)
+}
+
+const exportHTMLTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Wishlist.Title}}</title>
+<style>
+body { font-family: Georgia, serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.occasion { color: #555; margin-top: 0; }
+table { border-collapse: collapse; width: 100%; margin-top: 1.5em; }
+th, td { border-bottom: 1px solid #ccc; padding: 0.4em 0.6em; text-align: left; vertical-align: top; }
+th { border-bottom: 2px solid #222; }
+td.num { text-align: right; white-space: nowrap; }
+.details, .tags { color: #555; font-size: 0.9em; }
+a { color: inherit; }
+@media print { body { margin: 0; } a { text-decoration: none; } }
+</style>
+</head>
+<body>
+<h1>{{.Wishlist.Title}}</h1>
+{{if .Wishlist.Occasion}}<p class="occasion">{{.Wishlist.Occasion}}{{if .Wishlist.OccasionDate}}, {{date .Wishlist.OccasionDate}}{{end}}</p>{{end}}
+{{if .Wishlist.Description}}<p>{{.Wishlist.Description}}</p>{{end}}
+<table>
+<thead>
+<tr><th>Item</th><th>Price</th><th>Quantity</th>{{if .ShowReservations}}<th>Remaining</th>{{end}}</tr>
+</thead>
+<tbody>
+{{range .Items}}<tr>
+<td><a href="{{.Url}}">{{.Name}}</a>
+{{if or .Size .Color}}<div class="details">{{if .Size}}Size: {{.Size}} {{end}}{{if .Color}}Color: {{.Color}}{{end}}</div>{{end}}
+{{if .Notes}}<div class="details">{{.Notes}}</div>{{end}}
+{{if .Tags}}<div class="tags">{{range $i, $tag := .Tags}}{{if $i}}, {{end}}{{$tag}}{{end}}</div>{{end}}</td>
+<td class="num">{{if .Price}}{{price .Price}} {{.Currency}}{{end}}</td>
+<td class="num">{{.Quantity}}</td>{{if $.ShowReservations}}
+<td class="num">{{.Remaining}}</td>{{end}}
+</tr>
+{{else}}<tr><td colspan="4">No items</td></tr>
+{{end}}</tbody>
+</table>
+</body>
+</html>
+`
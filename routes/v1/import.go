@@ -0,0 +1,256 @@
+package v1
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/jatgam/wishlist-api/metrics"
+	"github.com/jatgam/wishlist-api/microservice"
+	"github.com/jatgam/wishlist-api/types"
+)
+
+const (
+	// maxImportRows caps how many items a single import can add.
+	maxImportRows = 1000
+	// itemsDocumentVersion is the version of the JSON import and export format.
+	itemsDocumentVersion = 1
+	// maxTagLength is the longest tag name, the same as tagForm allows.
+	maxTagLength = 64
+)
+
+var (
+	errImportFormat   = errors.New("Import format must be csv or json")
+	errImportTooLarge = fmt.Errorf("Imports are limited to %v items", maxImportRows)
+	errImportVersion  = fmt.Errorf("Import documents must be version %v or lower", itemsDocumentVersion)
+)
+
+type importItemsForm struct {
+	ListID *int   `form:"listid" binding:"omitempty,numeric"`
+	Format string `form:"format" binding:"omitempty,oneof=csv json"`
+	DryRun bool   `form:"dryrun"`
+}
+
+// itemsDocument is the JSON import format, which the JSON export also
+// follows. A bare array of items is accepted as well.
+type itemsDocument struct {
+	Version int                      `json:"version"`
+	Items   []map[string]interface{} `json:"items"`
+}
+
+func (a *api) importItems(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("ImportItems: Unauthorized: %s", err.Error())
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var importInfo importItemsForm
+	if err := c.ShouldBind(&importInfo); err != nil {
+		mylogger.Debug("importItems Failed Form Data Validation")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemAddDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	rows, err := readImportFile(c, importInfo.Format)
+	if err != nil {
+		mylogger.Debugf("importItems Failed to read the import file: %s", err.Error())
+		metrics.ItemErrors.WithLabelValues(metrics.ItemAddDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	items, rowErrors := bindImportRows(rows)
+	if len(items) == 0 && len(rowErrors) > 0 {
+		mylogger.Debug("importItems No valid rows to import")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemAddDataValidationError).Inc()
+		types.WriteImportResponse(c, http.StatusUnprocessableEntity, "No valid items to import",
+			importInfo.DryRun, 0, rowErrors)
+		return
+	}
+
+	imported, err := a.svc.ImportItems(importInfo.ListID, items, userID, isAdmin(c), importInfo.DryRun, mylogger)
+	if err != nil {
+		mylogger.Error("Item Import Failed.")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemAddError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	message := "Items Imported."
+	if importInfo.DryRun {
+		message = "Items Checked, nothing was imported."
+	}
+	mylogger.Infof("Imported %v items, dry run: %v", imported, importInfo.DryRun)
+	types.WriteImportResponse(c, http.StatusOK, message, importInfo.DryRun, imported, rowErrors)
+}
+
+// readImportFile reads the uploaded file into rows of item form values. The
+// format comes from the format field, or the files extension.
+func readImportFile(c *gin.Context, format string) ([]url.Values, error) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch format {
+	case "csv":
+		return readImportCSV(file)
+	case "json":
+		return readImportJSON(file)
+	default:
+		return nil, errImportFormat
+	}
+}
+
+// readImportCSV expects a header row naming the columns with the same names as
+// the add item form fields. The tags column separates tags with semicolons, as
+// the csv export does.
+func readImportCSV(r io.Reader) ([]url.Values, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the csv header: %s", err.Error())
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	rows := []url.Values{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the csv: %s", err.Error())
+		}
+		if len(rows) == maxImportRows {
+			return nil, errImportTooLarge
+		}
+		row := url.Values{}
+		for i, value := range record {
+			if value == "" || i >= len(header) {
+				continue
+			}
+			if header[i] == "tags" {
+				for _, tag := range strings.Split(value, ";") {
+					row.Add(header[i], tag)
+				}
+				continue
+			}
+			row.Set(header[i], value)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func readImportJSON(r io.Reader) ([]url.Values, error) {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var doc itemsDocument
+	if err := json.Unmarshal(body, &doc.Items); err != nil {
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, fmt.Errorf("Failed to read the json: %s", err.Error())
+		}
+	}
+	if doc.Version > itemsDocumentVersion {
+		return nil, errImportVersion
+	}
+	if len(doc.Items) > maxImportRows {
+		return nil, errImportTooLarge
+	}
+	rows := []url.Values{}
+	for _, item := range doc.Items {
+		row := url.Values{}
+		for key, value := range item {
+			switch v := value.(type) {
+			case string:
+				row.Set(strings.ToLower(key), v)
+			case float64:
+				row.Set(strings.ToLower(key), strconv.FormatFloat(v, 'f', -1, 64))
+			case bool:
+				row.Set(strings.ToLower(key), strconv.FormatBool(v))
+			case []interface{}:
+				// Only tags are a list, each one is kept as a value.
+				for _, element := range v {
+					if s, ok := element.(string); ok {
+						row.Add(strings.ToLower(key), s)
+					}
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// bindImportRows validates each row with the same rules as adding a single
+// item, and its tags with the rules for naming a tag. Rows are numbered from
+// 1, not counting a csv header.
+func bindImportRows(rows []url.Values) ([]types.ItemImport, []types.ImportRowError) {
+	items := []types.ItemImport{}
+	rowErrors := []types.ImportRowError{}
+	for i, row := range rows {
+		// The wishlist comes from the import request, not the rows.
+		row.Del("listid")
+		req, _ := http.NewRequest(http.MethodPost, "", strings.NewReader(row.Encode()))
+		req.Header.Set("Content-Type", binding.MIMEPOSTForm)
+		var newItem addItemForm
+		if err := binding.Form.Bind(req, &newItem); err != nil {
+			rowErrors = append(rowErrors, types.ImportRowError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		tags, err := importTags(row["tags"])
+		if err != nil {
+			rowErrors = append(rowErrors, types.ImportRowError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		items = append(items, types.ItemImport{Name: strings.TrimSpace(newItem.Name),
+			URL: strings.TrimSpace(newItem.URL), Rank: newItem.Rank, Details: newItem.toItemDetails(), Tags: tags})
+	}
+	return items, rowErrors
+}
+
+// importTags trims the tag names of a row, dropping blank and repeated ones.
+func importTags(names []string) ([]string, error) {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if utf8.RuneCountInString(name) > maxTagLength {
+			return nil, fmt.Errorf("Tag %q is longer than %v characters", name, maxTagLength)
+		}
+		seen[name] = true
+		tags = append(tags, name)
+	}
+	return tags, nil
+}
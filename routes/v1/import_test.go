@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/jatgam/wishlist-api/models"
+)
+
+// importFile uploads the file to the import route for the list.
+func (a *testAPI) importFile(t *testing.T, token string, listID int, filename string, file []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("listid", strconv.Itoa(listID))
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("Creating the upload failed: %s", err.Error())
+	}
+	part.Write(file)
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/item/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestImportExportedTags(t *testing.T) {
+	a := newTestAPI(t)
+	defer a.conn.Close()
+
+	owner, token := a.createUser(t, "owner")
+	source := &models.WishlistModel{OwnerID: owner.ID, Title: "Birthday"}
+	if err := a.lists.CreateWishlist(source); err != nil {
+		t.Fatalf("Creating the wishlist failed: %s", err.Error())
+	}
+	books := &models.TagModel{OwnerID: owner.ID, Name: "Books"}
+	gifts := &models.TagModel{OwnerID: owner.ID, Name: "Gift; wrapped"}
+	for _, tag := range []*models.TagModel{books, gifts} {
+		if err := a.tags.CreateTag(tag); err != nil {
+			t.Fatalf("Creating tag %s failed: %s", tag.Name, err.Error())
+		}
+	}
+	item := &models.ItemModel{Name: "Novel", URL: "https://example.com/novel", OwnerID: &owner.ID,
+		WishlistID: &source.ID, Rank: 1, Quantity: 1}
+	if err := a.items.AddItem(item); err != nil {
+		t.Fatalf("Adding the item failed: %s", err.Error())
+	}
+	for _, tag := range []*models.TagModel{books, gifts} {
+		if err := a.tags.TagItem(item, tag); err != nil {
+			t.Fatalf("Tagging the item failed: %s", err.Error())
+		}
+	}
+
+	for _, test := range []struct {
+		format string
+		want   []string
+	}{
+		// The csv separates tags with semicolons, so a tag containing one
+		// comes back split.
+		{"csv", []string{"Books", "Gift", "wrapped"}},
+		{"json", []string{"Books", "Gift; wrapped"}},
+	} {
+		export := a.do(http.MethodGet, fmt.Sprintf("/list/id/%d/export?format=%s", source.ID, test.format), token, nil)
+		if export.Code != http.StatusOK {
+			t.Fatalf("Exporting %s returned %v: %s", test.format, export.Code, export.Body.String())
+		}
+		target := &models.WishlistModel{OwnerID: owner.ID, Title: "Copy as " + test.format}
+		if err := a.lists.CreateWishlist(target); err != nil {
+			t.Fatalf("Creating the wishlist failed: %s", err.Error())
+		}
+		if w := a.importFile(t, token, target.ID, "items."+test.format, export.Body.Bytes()); w.Code != http.StatusOK {
+			t.Fatalf("Importing %s returned %v: %s", test.format, w.Code, w.Body.String())
+		}
+
+		resp := a.getItems(t, fmt.Sprintf("/list/id/%d/items/all", target.ID), token)
+		if len(*resp.Items) != 1 {
+			t.Fatalf("Imported %v items from %s, want 1", len(*resp.Items), test.format)
+		}
+		if got := (*resp.Items)[0].Tags; fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("Item imported from %s has tags %q, want %q", test.format, got, test.want)
+		}
+	}
+
+	tags, err := a.tags.GetTags(owner.ID)
+	if err != nil {
+		t.Fatalf("Getting the tags failed: %s", err.Error())
+	}
+	if len(*tags) != 4 {
+		t.Errorf("Owner has %v tags, want the 2 tags plus the 2 split from the csv", len(*tags))
+	}
+}
@@ -0,0 +1,114 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+
+	"github.com/jatgam/wishlist-api/metrics"
+	"github.com/jatgam/wishlist-api/microservice"
+	"github.com/jatgam/wishlist-api/types"
+)
+
+// inviteForm mints an invite, single use and never expiring by default.
+type inviteForm struct {
+	MaxUses   int           `form:"maxuses" binding:"omitempty,min=1,max=1000"`
+	ExpiresIn time.Duration `form:"expiresin" binding:"omitempty,min=0"`
+}
+
+type inviteURI struct {
+	InviteID int `uri:"inviteID" binding:"required,numeric,notblank"`
+}
+
+// getInvites lists every invite (Admin only).
+func (a *api) getInvites(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	if !isAdmin(c) {
+		mylogger.Debug("GetInvites: Unauthorized")
+		metrics.InviteErrors.WithLabelValues(metrics.InviteGetError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	invites, err := a.svc.GetInvites(mylogger)
+	if err != nil {
+		mylogger.Errorf("Failed to GetInvites: %s", err.Error())
+		metrics.InviteErrors.WithLabelValues(metrics.InviteGetError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	types.WriteInvitesResponse(c, http.StatusOK, "Got a list of invites", invites)
+}
+
+// addInvite mints an invite and returns its code (Admin only).
+func (a *api) addInvite(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil || !isAdmin(c) {
+		mylogger.Debug("AddInvite: Unauthorized")
+		metrics.InviteErrors.WithLabelValues(metrics.InviteAddError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var newInvite inviteForm
+	if err := c.ShouldBind(&newInvite); err != nil {
+		mylogger.Debug("addInvite Failed Form Data Validation")
+		metrics.InviteErrors.WithLabelValues(metrics.InviteDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if newInvite.MaxUses == 0 {
+		newInvite.MaxUses = 1
+	}
+
+	invite, err := a.svc.AddInvite(userID, newInvite.MaxUses, newInvite.ExpiresIn, mylogger)
+	if err != nil {
+		mylogger.Error("Invite Add Failed.")
+		metrics.InviteErrors.WithLabelValues(metrics.InviteAddError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Invite Created")
+	types.WriteInviteResponse(c, http.StatusOK, "Invite Created.", invite)
+}
+
+// deleteInvite revokes an invite (Admin only).
+func (a *api) deleteInvite(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	if !isAdmin(c) {
+		mylogger.Debug("DeleteInvite: Unauthorized")
+		metrics.InviteErrors.WithLabelValues(metrics.InviteDeleteError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var inviteInfo inviteURI
+	if err := c.ShouldBindUri(&inviteInfo); err != nil {
+		mylogger.Debug("Delete Invite Data Validation Error")
+		metrics.InviteErrors.WithLabelValues(metrics.InviteDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.DeleteInvite(inviteInfo.InviteID, mylogger); err != nil {
+		mylogger.Error("Failed to Delete Invite")
+		metrics.InviteErrors.WithLabelValues(metrics.InviteDeleteError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Invite Deleted")
+	types.WriteResponse(c, http.StatusOK, "Invite Deleted")
+}
+
+func (a *api) setupInviteRoutes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware) {
+	authMiddleware := ginjwt.MiddlewareFunc()
+	router.GET("", authMiddleware, a.getInvites)
+	router.POST("", authMiddleware, a.addInvite)
+	router.DELETE("/id/:inviteID", authMiddleware, a.deleteInvite)
+}
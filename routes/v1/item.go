@@ -9,7 +9,6 @@ import (
 
 	"github.com/jatgam/wishlist-api/metrics"
 	"github.com/jatgam/wishlist-api/microservice"
-	"github.com/jatgam/wishlist-api/service"
 	"github.com/jatgam/wishlist-api/types"
 )
 
@@ -17,6 +16,43 @@ type addItemForm struct {
 	Name string `form:"name" binding:"required,notblank,alphanumunicode"`
 	URL  string `form:"url" binding:"required,notblank,url"`
 	Rank int    `form:"rank" binding:"required,notblank,numeric"`
+	// ListID is the wishlist the item belongs to. Items without a list are
+	// served from the root item routes.
+	ListID   *int     `form:"listid" binding:"omitempty,numeric"`
+	Price    *float64 `form:"price" binding:"omitempty,price"`
+	Currency string   `form:"currency" binding:"required_with=Price,omitempty,currency"`
+	Quantity int      `form:"quantity" binding:"omitempty,min=1,max=1000"`
+	Notes    string   `form:"notes" binding:"max=1024"`
+	ImageURL string   `form:"imageurl" binding:"omitempty,url,max=255"`
+	Size     string   `form:"size" binding:"max=64"`
+	Color    string   `form:"color" binding:"max=64"`
+}
+
+func (f *addItemForm) toItemDetails() types.ItemDetails {
+	return types.ItemDetails{Price: f.Price, Currency: strings.TrimSpace(f.Currency),
+		Quantity: f.Quantity, Notes: strings.TrimSpace(f.Notes), ImageURL: strings.TrimSpace(f.ImageURL),
+		Size: strings.TrimSpace(f.Size), Color: strings.TrimSpace(f.Color)}
+}
+
+// editItemForm holds the fields that can be changed on an item. Fields that
+// aren't supplied are left unchanged.
+type editItemForm struct {
+	Name     *string  `form:"name" binding:"omitempty,notblank,alphanumunicode"`
+	URL      *string  `form:"url" binding:"omitempty,notblank,url"`
+	Rank     *int     `form:"rank" binding:"omitempty,notblank,numeric"`
+	Price    *float64 `form:"price" binding:"omitempty,price"`
+	Currency *string  `form:"currency" binding:"omitempty,currency"`
+	Quantity *int     `form:"quantity" binding:"omitempty,min=1,max=1000"`
+	Notes    *string  `form:"notes" binding:"omitempty,max=1024"`
+	ImageURL *string  `form:"imageurl" binding:"omitempty,url,max=255"`
+	Size     *string  `form:"size" binding:"omitempty,max=64"`
+	Color    *string  `form:"color" binding:"omitempty,max=64"`
+}
+
+func (f *editItemForm) toItemEdit() types.ItemEdit {
+	return types.ItemEdit{Name: trimmedOrNil(f.Name), URL: trimmedOrNil(f.URL), Rank: f.Rank,
+		Price: f.Price, Currency: trimmedOrNil(f.Currency), Quantity: f.Quantity, Notes: trimmedOrNil(f.Notes),
+		ImageURL: trimmedOrNil(f.ImageURL), Size: trimmedOrNil(f.Size), Color: trimmedOrNil(f.Color)}
 }
 
 type itemRankEditURI struct {
@@ -24,23 +60,67 @@ type itemRankEditURI struct {
 	Rank   int `uri:"rank" binding:"required,numeric,notblank"`
 }
 
+type reserveItemForm struct {
+	Quantity int `form:"quantity" binding:"omitempty,min=1,max=1000"`
+}
+
 type itemURI struct {
 	ItemID int `uri:"itemID" binding:"required,numeric,notblank"`
 }
 
-func getWantedItems(c *gin.Context) {
+type itemTagURI struct {
+	ItemID int `uri:"itemID" binding:"required,numeric,notblank"`
+	TagID  int `uri:"tagID" binding:"required,numeric,notblank"`
+}
+
+// itemListQuery holds the paging, filter and sort query parameters of the item
+// listings.
+type itemListQuery struct {
+	Limit    int      `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor   string   `form:"cursor"`
+	Status   string   `form:"status" binding:"omitempty,oneof=wanted reserved purchased received archived"`
+	MinPrice *float64 `form:"minprice" binding:"omitempty,price"`
+	MaxPrice *float64 `form:"maxprice" binding:"omitempty,price"`
+	Name     string   `form:"name" binding:"omitempty,max=255"`
+	Tag      string   `form:"tag" binding:"omitempty,max=64"`
+	Sort     string   `form:"sort" binding:"omitempty,oneof=rank created price"`
+	Order    string   `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+func (q itemListQuery) toItemQuery() types.ItemQuery {
+	return types.ItemQuery{Status: q.Status, MinPrice: q.MinPrice, MaxPrice: q.MaxPrice,
+		Name: strings.TrimSpace(q.Name), Tag: strings.TrimSpace(q.Tag), Sort: q.Sort, Desc: q.Order == "desc", Limit: q.Limit, Cursor: q.Cursor}
+}
+
+func bindItemListQuery(c *gin.Context) (types.ItemQuery, bool) {
 	mylogger := microservice.GetLogger(c)
-	items, err := service.GetWantedItems(mylogger)
+	var query itemListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		mylogger.Debug("Item Listing Query Validation Error")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemGetError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return types.ItemQuery{}, false
+	}
+	return query.toItemQuery(), true
+}
+
+func (a *api) getWantedItems(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	query, ok := bindItemListQuery(c)
+	if !ok {
+		return
+	}
+	page, err := a.svc.GetWantedItems(query, mylogger)
 
 	if err != nil {
 		mylogger.Error("Failed to get Wanted Items")
 		metrics.ItemErrors.WithLabelValues(metrics.ItemGetError).Inc()
-		types.WriteResponse(c, http.StatusInternalServerError, err.Error())
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
 		return
 	}
 
 	mylogger.Info("Got Wanted Items")
-	types.WriteItemResponse(c, http.StatusOK, "Got a list of Wanted items", items)
+	types.WriteItemPageResponse(c, http.StatusOK, "Got a list of Wanted items", page)
 }
 
 func isAuthorized(c *gin.Context, requiredlevel float64) bool {
@@ -80,11 +160,12 @@ func getAuthenticatedUsersID(c *gin.Context) (int, error) {
 	return 0, types.ErrDeterminingUserIDFromJWT
 }
 
-func addItem(c *gin.Context) {
+func (a *api) addItem(c *gin.Context) {
 	mylogger := microservice.GetLogger(c)
 	var newItem addItemForm
-	if !isAuthorized(c, 9) {
-		mylogger.Debug("AddItem: Unauthorized")
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("AddItem: Unauthorized: %s", err.Error())
 		types.WriteResponse(c, http.StatusUnauthorized, "Unathorized Access")
 		return
 	}
@@ -96,40 +177,46 @@ func addItem(c *gin.Context) {
 		return
 	}
 
-	if err := service.AddItem(strings.TrimSpace(newItem.Name), strings.TrimSpace(newItem.URL), newItem.Rank, mylogger); err != nil {
+	if err := a.svc.AddItem(strings.TrimSpace(newItem.Name), strings.TrimSpace(newItem.URL), newItem.Rank,
+		newItem.ListID, newItem.toItemDetails(), userID, isAdmin(c), mylogger); err != nil {
 		mylogger.Error("Item Add Failed.")
 		metrics.ItemErrors.WithLabelValues(metrics.ItemAddError).Inc()
-		types.WriteResponse(c, http.StatusInternalServerError, err.Error())
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
 		return
 	}
 
-	mylogger.Debug("Authorized to add Items")
+	mylogger.Debug("Item Added")
 	types.WriteResponse(c, http.StatusOK, "Item Created.")
 }
 
-func getAllItems(c *gin.Context) {
+func (a *api) getAllItems(c *gin.Context) {
 	mylogger := microservice.GetLogger(c)
-	if !isAuthorized(c, 9) {
+	if !isAdmin(c) {
 		mylogger.Debug("GetAllItems: Unauthorized")
 		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
 		return
 	}
 
-	items, err := service.GetAllItems(mylogger)
+	query, ok := bindItemListQuery(c)
+	if !ok {
+		return
+	}
+	userID, _ := getViewer(c)
+	page, err := a.svc.GetAllItems(userID, query, mylogger)
 
 	if err != nil {
 		mylogger.Error("Failed to get All Items")
 		metrics.ItemErrors.WithLabelValues(metrics.ItemGetError).Inc()
-		types.WriteResponse(c, http.StatusInternalServerError, err.Error())
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
 		return
 	}
 
 	mylogger.Info("Got All Items")
-	types.WriteItemResponse(c, http.StatusOK, "Got a list of items", items)
+	types.WriteItemPageResponse(c, http.StatusOK, "Got a list of items", page)
 
 }
 
-func getReservedItems(c *gin.Context) {
+func (a *api) getReservedItems(c *gin.Context) {
 	mylogger := microservice.GetLogger(c)
 	userID, err := getAuthenticatedUsersID(c)
 	if err != nil {
@@ -138,24 +225,28 @@ func getReservedItems(c *gin.Context) {
 		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
 		return
 	}
-	items, err := service.GetReservedItems(userID, mylogger)
+	query, ok := bindItemListQuery(c)
+	if !ok {
+		return
+	}
+	page, err := a.svc.GetReservedItems(userID, query, mylogger)
 
 	if err != nil {
 		mylogger.Error("Failed to get Reserved Items")
 		metrics.ItemErrors.WithLabelValues(metrics.ItemGetError).Inc()
-		types.WriteResponse(c, http.StatusInternalServerError, err.Error())
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
 		return
 	}
 
 	mylogger.Info("Got Reserved Items")
-	types.WriteItemResponse(c, http.StatusOK, "Got a list of reserved items", items)
+	types.WriteItemPageResponse(c, http.StatusOK, "Got a list of reserved items", page)
 }
 
-func deleteItem(c *gin.Context) {
+func (a *api) deleteItem(c *gin.Context) {
 	mylogger := microservice.GetLogger(c)
-
-	if !isAuthorized(c, 9) {
-		mylogger.Debug("DeleteItem: Unauthorized")
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("DeleteItem: Unauthorized: %s", err.Error())
 		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
 		return
 	}
@@ -168,10 +259,10 @@ func deleteItem(c *gin.Context) {
 		return
 	}
 
-	if deleteError := service.DeleteItem(itemInfo.ItemID, mylogger); deleteError != nil {
+	if deleteError := a.svc.DeleteItem(itemInfo.ItemID, userID, isAdmin(c), mylogger); deleteError != nil {
 		mylogger.Error("Failed to Delete Item")
 		metrics.ItemErrors.WithLabelValues(metrics.ItemDeleteError).Inc()
-		types.WriteResponse(c, http.StatusInternalServerError, deleteError.Error())
+		types.WriteResponse(c, serviceErrorStatus(deleteError), deleteError.Error())
 		return
 	}
 
@@ -179,7 +270,7 @@ func deleteItem(c *gin.Context) {
 	types.WriteResponse(c, http.StatusOK, "Item Deleted")
 }
 
-func reserveItem(c *gin.Context) {
+func (a *api) reserveItem(c *gin.Context) {
 	mylogger := microservice.GetLogger(c)
 	userID, err := getAuthenticatedUsersID(c)
 	if err != nil {
@@ -196,11 +287,22 @@ func reserveItem(c *gin.Context) {
 		return
 	}
 
-	reserveErr := service.ReserveItem(userID, itemInfo.ItemID, mylogger)
+	var reserveInfo reserveItemForm
+	if err := c.ShouldBind(&reserveInfo); err != nil {
+		mylogger.Debug("Reserve Item Form Data Validation Error")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if reserveInfo.Quantity == 0 {
+		reserveInfo.Quantity = 1
+	}
+
+	reserveErr := a.svc.ReserveItem(userID, itemInfo.ItemID, reserveInfo.Quantity, isAdmin(c), mylogger)
 	if reserveErr != nil {
 		mylogger.Error("Failed to Reserve Item")
 		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
-		types.WriteResponse(c, http.StatusInternalServerError, err.Error())
+		types.WriteResponse(c, serviceErrorStatus(reserveErr), reserveErr.Error())
 		return
 	}
 
@@ -208,7 +310,7 @@ func reserveItem(c *gin.Context) {
 	types.WriteResponse(c, http.StatusOK, "Item Reserved")
 }
 
-func unReserveItem(c *gin.Context) {
+func (a *api) unReserveItem(c *gin.Context) {
 	mylogger := microservice.GetLogger(c)
 	userID, err := getAuthenticatedUsersID(c)
 	if err != nil {
@@ -225,11 +327,11 @@ func unReserveItem(c *gin.Context) {
 		return
 	}
 
-	unreserveErr := service.UnReserveItem(userID, itemInfo.ItemID, mylogger)
+	unreserveErr := a.svc.UnReserveItem(userID, itemInfo.ItemID, mylogger)
 	if unreserveErr != nil {
 		mylogger.Error("Failed to UnReserve Item")
 		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
-		types.WriteResponse(c, http.StatusInternalServerError, err.Error())
+		types.WriteResponse(c, serviceErrorStatus(unreserveErr), unreserveErr.Error())
 		return
 	}
 
@@ -237,11 +339,11 @@ func unReserveItem(c *gin.Context) {
 	types.WriteResponse(c, http.StatusOK, "Item UnReserved")
 }
 
-func editItemRank(c *gin.Context) {
+func (a *api) editItemRank(c *gin.Context) {
 	mylogger := microservice.GetLogger(c)
-
-	if !isAuthorized(c, 9) {
-		mylogger.Debug("EditItemRank: Unauthorized")
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("EditItemRank: Unauthorized: %s", err.Error())
 		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
 		return
 	}
@@ -253,12 +355,12 @@ func editItemRank(c *gin.Context) {
 		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
-	err := service.EditItemRank(itemInfo.ItemID, itemInfo.Rank, mylogger)
+	err = a.svc.EditItemRank(itemInfo.ItemID, itemInfo.Rank, userID, isAdmin(c), mylogger)
 
 	if err != nil {
 		mylogger.Error("Failed to Edit Item")
 		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
-		types.WriteResponse(c, http.StatusInternalServerError, err.Error())
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
 		return
 	}
 
@@ -266,14 +368,255 @@ func editItemRank(c *gin.Context) {
 	types.WriteResponse(c, http.StatusOK, "Item Rank Updated")
 }
 
-func setupItemRoutes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware) {
+func (a *api) editItem(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("EditItem: Unauthorized: %s", err.Error())
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var itemInfo itemURI
+	if err := c.ShouldBindUri(&itemInfo); err != nil {
+		mylogger.Debug("Edit Item Data Validation Error")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	var itemEdit editItemForm
+	if err := c.ShouldBind(&itemEdit); err != nil {
+		mylogger.Debug("editItem Failed Form Data Validation")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	err = a.svc.EditItem(itemInfo.ItemID, userID, isAdmin(c), itemEdit.toItemEdit(), mylogger)
+	if err != nil {
+		mylogger.Error("Failed to Edit Item")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Item Edited")
+	types.WriteResponse(c, http.StatusOK, "Item Updated")
+}
+
+func (a *api) purchaseItem(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to PurchaseItem: %s", err.Error())
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+	var itemInfo itemURI
+	if err := c.ShouldBindUri(&itemInfo); err != nil {
+		mylogger.Debug("Purchase Item Data Validation Error")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.PurchaseItem(userID, itemInfo.ItemID, mylogger); err != nil {
+		mylogger.Error("Failed to Purchase Item")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Item Purchased")
+	types.WriteResponse(c, http.StatusOK, "Item Purchased")
+}
+
+func (a *api) unPurchaseItem(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to UnPurchaseItem: %s", err.Error())
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+	var itemInfo itemURI
+	if err := c.ShouldBindUri(&itemInfo); err != nil {
+		mylogger.Debug("UnPurchase Item Data Validation Error")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.UnPurchaseItem(userID, itemInfo.ItemID, mylogger); err != nil {
+		mylogger.Error("Failed to UnPurchase Item")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Item UnPurchased")
+	types.WriteResponse(c, http.StatusOK, "Item UnPurchased")
+}
+
+func (a *api) receiveItem(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to ReceiveItem: %s", err.Error())
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+	var itemInfo itemURI
+	if err := c.ShouldBindUri(&itemInfo); err != nil {
+		mylogger.Debug("Receive Item Data Validation Error")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.ReceiveItem(itemInfo.ItemID, userID, isAdmin(c), mylogger); err != nil {
+		mylogger.Error("Failed to Receive Item")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Item Received")
+	types.WriteResponse(c, http.StatusOK, "Item Received")
+}
+
+func (a *api) archiveItem(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to ArchiveItem: %s", err.Error())
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+	var itemInfo itemURI
+	if err := c.ShouldBindUri(&itemInfo); err != nil {
+		mylogger.Debug("Archive Item Data Validation Error")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.ArchiveItem(itemInfo.ItemID, userID, isAdmin(c), mylogger); err != nil {
+		mylogger.Error("Failed to Archive Item")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Item Archived")
+	types.WriteResponse(c, http.StatusOK, "Item Archived")
+}
+
+func (a *api) restoreItem(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to RestoreItem: %s", err.Error())
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+	var itemInfo itemURI
+	if err := c.ShouldBindUri(&itemInfo); err != nil {
+		mylogger.Debug("Restore Item Data Validation Error")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.RestoreItem(itemInfo.ItemID, userID, isAdmin(c), mylogger); err != nil {
+		mylogger.Error("Failed to Restore Item")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Item Restored")
+	types.WriteResponse(c, http.StatusOK, "Item Restored")
+}
+
+func (a *api) tagItem(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("TagItem: Unauthorized: %s", err.Error())
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var itemInfo itemTagURI
+	if err := c.ShouldBindUri(&itemInfo); err != nil {
+		mylogger.Debug("Tag Item Data Validation Error")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.TagItem(itemInfo.ItemID, itemInfo.TagID, userID, isAdmin(c), mylogger); err != nil {
+		mylogger.Error("Failed to Tag Item")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Item Tagged")
+	types.WriteResponse(c, http.StatusOK, "Item Tagged")
+}
+
+func (a *api) untagItem(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("UntagItem: Unauthorized: %s", err.Error())
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var itemInfo itemTagURI
+	if err := c.ShouldBindUri(&itemInfo); err != nil {
+		mylogger.Debug("Untag Item Data Validation Error")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.UntagItem(itemInfo.ItemID, itemInfo.TagID, userID, isAdmin(c), mylogger); err != nil {
+		mylogger.Error("Failed to Untag Item")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemEditError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Item Untagged")
+	types.WriteResponse(c, http.StatusOK, "Item Untagged")
+}
+
+func (a *api) setupItemRoutes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware) {
 	authMiddleware := ginjwt.MiddlewareFunc()
-	router.GET("", getWantedItems)
-	router.POST("", authMiddleware, addItem)
-	router.GET("/all", authMiddleware, getAllItems)
-	router.GET("/reserved", authMiddleware, getReservedItems)
-	router.DELETE("/id/:itemID", authMiddleware, deleteItem)
-	router.POST("/id/:itemID/reserve", authMiddleware, reserveItem)
-	router.POST("/id/:itemID/unreserve", authMiddleware, unReserveItem)
-	router.POST("/id/:itemID/rank/:rank", authMiddleware, editItemRank)
+	router.GET("", a.getWantedItems)
+	router.POST("", authMiddleware, a.addItem)
+	router.POST("/import", authMiddleware, a.importItems)
+	router.GET("/all", authMiddleware, a.getAllItems)
+	router.GET("/reserved", authMiddleware, a.getReservedItems)
+	router.PATCH("/id/:itemID", authMiddleware, a.editItem)
+	router.DELETE("/id/:itemID", authMiddleware, a.deleteItem)
+	router.POST("/id/:itemID/reserve", authMiddleware, a.reserveItem)
+	router.POST("/id/:itemID/unreserve", authMiddleware, a.unReserveItem)
+	router.POST("/id/:itemID/purchase", authMiddleware, a.purchaseItem)
+	router.POST("/id/:itemID/unpurchase", authMiddleware, a.unPurchaseItem)
+	router.POST("/id/:itemID/receive", authMiddleware, a.receiveItem)
+	router.POST("/id/:itemID/archive", authMiddleware, a.archiveItem)
+	router.POST("/id/:itemID/restore", authMiddleware, a.restoreItem)
+	router.POST("/id/:itemID/rank/:rank", authMiddleware, a.editItemRank)
+	router.POST("/id/:itemID/tag/:tagID", authMiddleware, a.tagItem)
+	router.DELETE("/id/:itemID/tag/:tagID", authMiddleware, a.untagItem)
 }
@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"net/http"
+	"strings"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+
+	"github.com/jatgam/wishlist-api/metrics"
+	"github.com/jatgam/wishlist-api/microservice"
+	"github.com/jatgam/wishlist-api/types"
+)
+
+type tagForm struct {
+	Name string `form:"name" binding:"required,notblank,max=64"`
+}
+
+type tagURI struct {
+	TagID int `uri:"tagID" binding:"required,numeric,notblank"`
+}
+
+func (a *api) getTags(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to GetTags: %s", err.Error())
+		metrics.TagErrors.WithLabelValues(metrics.TagGetError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	tags, err := a.svc.GetTags(userID, mylogger)
+	if err != nil {
+		mylogger.Error("Failed to get Tags")
+		metrics.TagErrors.WithLabelValues(metrics.TagGetError).Inc()
+		types.WriteResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	mylogger.Info("Got Tags")
+	types.WriteTagsResponse(c, http.StatusOK, "Got a list of tags", tags)
+}
+
+func (a *api) addTag(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to AddTag: %s", err.Error())
+		metrics.TagErrors.WithLabelValues(metrics.TagAddError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var newTag tagForm
+	if err := c.ShouldBind(&newTag); err != nil {
+		mylogger.Debug("addTag Failed Form Data Validation")
+		metrics.TagErrors.WithLabelValues(metrics.TagDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.AddTag(userID, strings.TrimSpace(newTag.Name), mylogger); err != nil {
+		mylogger.Error("Tag Add Failed.")
+		metrics.TagErrors.WithLabelValues(metrics.TagAddError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Tag Created")
+	types.WriteResponse(c, http.StatusOK, "Tag Created.")
+}
+
+func (a *api) renameTag(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to RenameTag: %s", err.Error())
+		metrics.TagErrors.WithLabelValues(metrics.TagEditError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var tagInfo tagURI
+	if err := c.ShouldBindUri(&tagInfo); err != nil {
+		mylogger.Debug("Rename Tag Data Validation Error")
+		metrics.TagErrors.WithLabelValues(metrics.TagDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	var tagEdit tagForm
+	if err := c.ShouldBind(&tagEdit); err != nil {
+		mylogger.Debug("renameTag Failed Form Data Validation")
+		metrics.TagErrors.WithLabelValues(metrics.TagDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	err = a.svc.RenameTag(tagInfo.TagID, userID, isAdmin(c), strings.TrimSpace(tagEdit.Name), mylogger)
+	if err != nil {
+		mylogger.Error("Failed to Rename Tag")
+		metrics.TagErrors.WithLabelValues(metrics.TagEditError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Tag Renamed")
+	types.WriteResponse(c, http.StatusOK, "Tag Updated")
+}
+
+func (a *api) deleteTag(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to DeleteTag: %s", err.Error())
+		metrics.TagErrors.WithLabelValues(metrics.TagDeleteError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var tagInfo tagURI
+	if err := c.ShouldBindUri(&tagInfo); err != nil {
+		mylogger.Debug("Delete Tag Data Validation Error")
+		metrics.TagErrors.WithLabelValues(metrics.TagDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.DeleteTag(tagInfo.TagID, userID, isAdmin(c), mylogger); err != nil {
+		mylogger.Error("Failed to Delete Tag")
+		metrics.TagErrors.WithLabelValues(metrics.TagDeleteError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Tag Deleted")
+	types.WriteResponse(c, http.StatusOK, "Tag Deleted")
+}
+
+func (a *api) setupTagRoutes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware) {
+	authMiddleware := ginjwt.MiddlewareFunc()
+	router.GET("", authMiddleware, a.getTags)
+	router.POST("", authMiddleware, a.addTag)
+	router.PATCH("/id/:tagID", authMiddleware, a.renameTag)
+	router.DELETE("/id/:tagID", authMiddleware, a.deleteTag)
+}
@@ -7,9 +7,9 @@ import (
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
 
+	authjwt "github.com/jatgam/wishlist-api/jwt"
 	"github.com/jatgam/wishlist-api/metrics"
 	"github.com/jatgam/wishlist-api/microservice"
-	"github.com/jatgam/wishlist-api/service"
 	"github.com/jatgam/wishlist-api/types"
 )
 
@@ -19,6 +19,36 @@ type registerUserForm struct {
 	Email     string `form:"email" binding:"required,notblank,email"`
 	Firstname string `form:"firstname" binding:"required,notblank,alpha,min=1"`
 	Lastname  string `form:"lastname" binding:"required,notblank,alpha,min=1"`
+	// Invite is only needed while registration is invite only.
+	Invite string `form:"invite" binding:"omitempty,alphanum,max=64"`
+}
+
+// editProfileForm validates like registerUserForm, with every field optional.
+type editProfileForm struct {
+	Email     *string `form:"email" binding:"omitempty,notblank,email"`
+	Firstname *string `form:"firstname" binding:"omitempty,notblank,alpha,min=1"`
+	Lastname  *string `form:"lastname" binding:"omitempty,notblank,alpha,min=1"`
+}
+
+type verifyEMailURI struct {
+	VerifyToken string `uri:"verifyToken" binding:"required,notblank,max=128"`
+}
+
+type verifyEMailResendForm struct {
+	Email string `form:"email" binding:"required,notblank,email"`
+}
+
+type userURI struct {
+	UserID int `uri:"userID" binding:"required,numeric,notblank"`
+}
+
+type emailConfirmURI struct {
+	EMailToken string `uri:"emailToken" binding:"required,notblank,max=128"`
+}
+
+type changePasswordForm struct {
+	CurrentPassword string `form:"currentpassword" binding:"required,notblank"`
+	Password        string `form:"password" binding:"required,min=10,notblank,passcomplexity"`
 }
 
 type passwordForgotForm struct {
@@ -33,7 +63,7 @@ type passwordResetForm struct {
 	Email    string `form:"email" binding:"required,notblank,email"`
 }
 
-func registerUser(c *gin.Context) {
+func (a *api) registerUser(c *gin.Context) {
 	mylogger := microservice.GetLogger(c)
 	var newUser registerUserForm
 	if err := c.ShouldBind(&newUser); err != nil {
@@ -43,21 +73,63 @@ func registerUser(c *gin.Context) {
 		return
 	}
 
-	err := service.RegisterUser(strings.TrimSpace(newUser.Username), newUser.Password, strings.TrimSpace(newUser.Email),
-		strings.TrimSpace(newUser.Firstname), strings.TrimSpace(newUser.Lastname), mylogger)
+	err := a.svc.RegisterUser(strings.TrimSpace(newUser.Username), newUser.Password, strings.TrimSpace(newUser.Email),
+		strings.TrimSpace(newUser.Firstname), strings.TrimSpace(newUser.Lastname), newUser.Invite, c.Request.Host, mylogger)
 	if err != nil {
 		mylogger.Errorf("Failed to Register User: %s:%s", newUser.Username, newUser.Email)
 		metrics.UserError.WithLabelValues(metrics.UserRegFailedError).Inc()
-		types.WriteResponse(c, http.StatusInternalServerError, err.Error())
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
 		return
 	}
 
 	mylogger.Infof("Registered New User: %s:%s", newUser.Username, newUser.Email)
 	metrics.UsersRegister.Inc()
-	types.WriteResponse(c, http.StatusOK, "User Created.")
+	types.WriteResponse(c, http.StatusOK, "User Created. Sending an Email to verify the address.")
 }
 
-func passwordForgot(c *gin.Context) {
+func (a *api) verifyEMail(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	var verify verifyEMailURI
+	if err := c.ShouldBindUri(&verify); err != nil {
+		mylogger.Debug("Verify EMail Token Data Validation")
+		metrics.UserError.WithLabelValues(metrics.RequestDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.VerifyEMail(verify.VerifyToken, mylogger); err != nil {
+		mylogger.Errorf("Failed to VerifyEMail: %s", err.Error())
+		metrics.UserError.WithLabelValues(metrics.UserVerifyEMailError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("EMail Verified")
+	types.WriteResponse(c, http.StatusOK, "EMail Verified")
+}
+
+func (a *api) resendVerifyEMail(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	var resend verifyEMailResendForm
+	if err := c.ShouldBind(&resend); err != nil {
+		mylogger.Debug("Resend Verify EMail Failed Data Validation")
+		metrics.UserError.WithLabelValues(metrics.RequestDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.ResendVerifyEMail(strings.TrimSpace(resend.Email), c.Request.Host, mylogger); err != nil {
+		mylogger.Errorf("Failed to ResendVerifyEMail: %s", err.Error())
+		metrics.UserError.WithLabelValues(metrics.UserVerifyEMailResendError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Infof("Resent Verify EMail: %s", resend.Email)
+	types.WriteResponse(c, http.StatusOK, "Sending an Email to the provided address.")
+}
+
+func (a *api) passwordForgot(c *gin.Context) {
 	mylogger := microservice.GetLogger(c)
 	var pwForgot passwordForgotForm
 	if err := c.ShouldBind(&pwForgot); err != nil {
@@ -67,7 +139,7 @@ func passwordForgot(c *gin.Context) {
 		return
 	}
 
-	err := service.PasswordForgot(strings.TrimSpace(pwForgot.Email), c.Request.Host, mylogger)
+	err := a.svc.PasswordForgot(strings.TrimSpace(pwForgot.Email), c.Request.Host, mylogger)
 	if err != nil {
 		mylogger.Errorf("Failed to start password reset process: %s", pwForgot.Email)
 		metrics.UserError.WithLabelValues(metrics.UserPasswordForgotError).Inc()
@@ -79,7 +151,7 @@ func passwordForgot(c *gin.Context) {
 	types.WriteResponse(c, http.StatusOK, "Sending an Email to the provided address.")
 }
 
-func passwordResetTokenValidate(c *gin.Context) {
+func (a *api) passwordResetTokenValidate(c *gin.Context) {
 	mylogger := microservice.GetLogger(c)
 	var pwReset passwordResetURI
 	if err := c.ShouldBindUri(&pwReset); err != nil {
@@ -89,7 +161,7 @@ func passwordResetTokenValidate(c *gin.Context) {
 		return
 	}
 
-	valid, err := service.PasswordResetTokenValidate(strings.TrimSpace(pwReset.PWResetToken), mylogger)
+	valid, err := a.svc.PasswordResetTokenValidate(strings.TrimSpace(pwReset.PWResetToken), mylogger)
 	if err != nil && err == types.ErrPasswordResetValidateServerErr {
 		mylogger.Errorf("PasswordResetValidateToken failed to validate: %s", err.Error())
 		metrics.UserError.WithLabelValues(metrics.UserPasswordResetValidateError).Inc()
@@ -107,7 +179,7 @@ func passwordResetTokenValidate(c *gin.Context) {
 	types.WriteResponse(c, http.StatusOK, "Token Valid")
 }
 
-func passwordReset(c *gin.Context) {
+func (a *api) passwordReset(c *gin.Context) {
 	mylogger := microservice.GetLogger(c)
 	var pwReset passwordResetForm
 	var pwResetURI passwordResetURI
@@ -124,7 +196,7 @@ func passwordReset(c *gin.Context) {
 		return
 	}
 
-	err := service.PasswordReset(strings.TrimSpace(pwReset.Email), pwReset.Password, pwResetURI.PWResetToken, mylogger)
+	err := a.svc.PasswordReset(strings.TrimSpace(pwReset.Email), pwReset.Password, pwResetURI.PWResetToken, mylogger)
 	if err != nil {
 		mylogger.Errorf("PasswordReset failed to reset: %s : %s", pwReset.Email, err.Error())
 		metrics.UserError.WithLabelValues(metrics.UserPasswordResetError).Inc()
@@ -137,14 +209,168 @@ func passwordReset(c *gin.Context) {
 
 }
 
-func setupUserRoutes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware) {
+func (a *api) getProfile(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to GetProfile: %s", err.Error())
+		metrics.UserError.WithLabelValues(metrics.UserGetProfileError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
 
-	router.GET("/auth", ginjwt.MiddlewareFunc())
+	user, err := a.svc.GetProfile(userID, mylogger)
+	if err != nil {
+		mylogger.Errorf("Failed to GetProfile: %s", err.Error())
+		metrics.UserError.WithLabelValues(metrics.UserGetProfileError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	types.WriteUserResponse(c, http.StatusOK, "Got the profile", user)
+}
+
+func (a *api) editProfile(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to EditProfile: %s", err.Error())
+		metrics.UserError.WithLabelValues(metrics.UserEditProfileError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var profileEdit editProfileForm
+	if err := c.ShouldBind(&profileEdit); err != nil {
+		mylogger.Debug("EditProfile Failed Data Validation")
+		metrics.UserError.WithLabelValues(metrics.RequestDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	edit := types.ProfileEdit{EMail: trimmedOrNil(profileEdit.Email), FirstName: trimmedOrNil(profileEdit.Firstname),
+		LastName: trimmedOrNil(profileEdit.Lastname)}
+	emailPending, err := a.svc.EditProfile(userID, edit, c.Request.Host, mylogger)
+	if err != nil {
+		mylogger.Errorf("Failed to EditProfile: %s", err.Error())
+		metrics.UserError.WithLabelValues(metrics.UserEditProfileError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Infof("Profile Updated: %v", userID)
+	if emailPending {
+		types.WriteResponse(c, http.StatusOK, "Profile Updated. Sending an Email to confirm the new address.")
+		return
+	}
+	types.WriteResponse(c, http.StatusOK, "Profile Updated")
+}
+
+func (a *api) emailConfirm(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	var confirm emailConfirmURI
+	if err := c.ShouldBindUri(&confirm); err != nil {
+		mylogger.Debug("EMail Confirm Token Data Validation")
+		metrics.UserError.WithLabelValues(metrics.RequestDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.ConfirmEMail(confirm.EMailToken, mylogger); err != nil {
+		mylogger.Errorf("Failed to ConfirmEMail: %s", err.Error())
+		metrics.UserError.WithLabelValues(metrics.UserEMailConfirmError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("EMail Confirmed")
+	types.WriteResponse(c, http.StatusOK, "EMail Confirmed")
+}
+
+func (a *api) changePassword(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to ChangePassword: %s", err.Error())
+		metrics.UserError.WithLabelValues(metrics.UserChangePasswordError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var passwordChange changePasswordForm
+	if err := c.ShouldBind(&passwordChange); err != nil {
+		mylogger.Debug("ChangePassword Failed Data Validation")
+		metrics.UserError.WithLabelValues(metrics.RequestDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	err = a.svc.ChangePassword(userID, passwordChange.CurrentPassword, passwordChange.Password, mylogger)
+	if err != nil {
+		mylogger.Errorf("Failed to ChangePassword: %s", err.Error())
+		metrics.UserError.WithLabelValues(metrics.UserChangePasswordError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Infof("Password Changed: %v", userID)
+	types.WriteResponse(c, http.StatusOK, "Password Changed. Log in again with the new password.")
+}
+
+// requirePasswordChange makes a user change their password before they can
+// do anything else (Admin only).
+func (a *api) requirePasswordChange(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	if !isAdmin(c) {
+		mylogger.Debug("RequirePasswordChange: Unauthorized")
+		metrics.UserError.WithLabelValues(metrics.UserRequirePasswordError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var userInfo userURI
+	if err := c.ShouldBindUri(&userInfo); err != nil {
+		mylogger.Debug("RequirePasswordChange Data Validation Error")
+		metrics.UserError.WithLabelValues(metrics.RequestDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.RequirePasswordChange(userInfo.UserID, mylogger); err != nil {
+		mylogger.Errorf("Failed to RequirePasswordChange: %s", err.Error())
+		metrics.UserError.WithLabelValues(metrics.UserRequirePasswordError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Infof("Password Change Required: %v", userInfo.UserID)
+	types.WriteResponse(c, http.StatusOK, "Password Change Required")
+}
+
+func (a *api) setupUserRoutes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware) {
+
+	// Users that must change their password can still check and refresh their
+	// token, to get as far as changing it.
+	router.GET("/auth", authjwt.AllowPasswordReset, ginjwt.MiddlewareFunc())
 	router.POST("/auth", ginjwt.LoginHandler)
-	router.POST("/auth/refresh", ginjwt.RefreshHandler)
+	// The refresh handler doesn't run the Authorizator, so check the token
+	// isn't revoked first. Tokens can't be refreshed after they expire, as
+	// MaxRefresh is the same as the Timeout.
+	router.POST("/auth/refresh", authjwt.AllowPasswordReset, ginjwt.MiddlewareFunc(), ginjwt.RefreshHandler)
+
+	router.POST("/register", a.registerUser)
+	router.GET("/verify_email/:verifyToken", a.verifyEMail)
+	router.POST("/verify_email/resend", a.resendVerifyEMail)
+	router.POST("/password_forgot", a.passwordForgot)
+	router.GET("/password_reset/:pwResetToken", a.passwordResetTokenValidate)
+	router.POST("/password_reset/:pwResetToken", a.passwordReset)
+	router.GET("/email_confirm/:emailToken", a.emailConfirm)
+	router.POST("/id/:userID/require_password_change", ginjwt.MiddlewareFunc(), a.requirePasswordChange)
+}
 
-	router.POST("/register", registerUser)
-	router.POST("/password_forgot", passwordForgot)
-	router.GET("/password_reset/:pwResetToken", passwordResetTokenValidate)
-	router.POST("/password_reset/:pwResetToken", passwordReset)
+func (a *api) setupMeRoutes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware) {
+	authMiddleware := ginjwt.MiddlewareFunc()
+	router.GET("", authMiddleware, a.getProfile)
+	router.PATCH("", authMiddleware, a.editProfile)
+	router.POST("/password", authjwt.AllowPasswordReset, authMiddleware, a.changePassword)
 }
@@ -1,15 +1,104 @@
 package v1
 
 import (
+	"net/http"
+	"strings"
+
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
+
+	"github.com/jatgam/wishlist-api/service"
+	"github.com/jatgam/wishlist-api/types"
 )
 
+// adminUserLevel is the userlevel required for admin only actions.
+const adminUserLevel = 9
+
+// api holds what the V1 handlers depend on.
+type api struct {
+	svc *service.Service
+}
+
 // SetupV1Routes sets up the routes for the V1 API
-func SetupV1Routes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware) {
+func SetupV1Routes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware, svc *service.Service) {
+	a := &api{svc: svc}
+
 	userGroup := router.Group("/user")
-	setupUserRoutes(userGroup, ginjwt)
+	a.setupUserRoutes(userGroup, ginjwt)
 
 	itemGroup := router.Group("/item")
-	setupItemRoutes(itemGroup, ginjwt)
+	a.setupItemRoutes(itemGroup, ginjwt)
+
+	listGroup := router.Group("/list")
+	a.setupWishlistRoutes(listGroup, ginjwt)
+
+	tagGroup := router.Group("/tag")
+	a.setupTagRoutes(tagGroup, ginjwt)
+
+	meGroup := router.Group("/me")
+	a.setupMeRoutes(meGroup, ginjwt)
+
+	inviteGroup := router.Group("/invite")
+	a.setupInviteRoutes(inviteGroup, ginjwt)
+}
+
+// optionalAuthMiddleware only runs the jwt middleware when the request has an
+// Authorization header, so routes can be used anonymously but still know who
+// the viewer is when a token is supplied.
+func optionalAuthMiddleware(ginjwt *jwt.GinJWTMiddleware) gin.HandlerFunc {
+	authMiddleware := ginjwt.MiddlewareFunc()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		authMiddleware(c)
+	}
+}
+
+func isAdmin(c *gin.Context) bool {
+	return isAuthorized(c, adminUserLevel)
+}
+
+// getViewer returns the authenticated users ID and if they are an admin. An
+// anonymous viewer has an ID of 0.
+func getViewer(c *gin.Context) (int, bool) {
+	if _, found := jwt.ExtractClaims(c)["id"]; !found {
+		return 0, false
+	}
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		return 0, false
+	}
+	return userID, isAdmin(c)
+}
+
+// trimmedOrNil trims the whitespace of an optional form value.
+func trimmedOrNil(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	return &trimmed
+}
+
+// serviceErrorStatus maps service errors to the http status to return.
+func serviceErrorStatus(err error) int {
+	switch err {
+	case types.ErrWishlistNotFound, types.ErrItemNotFound, types.ErrTagNotFound, types.ErrUserNotFound,
+		types.ErrInviteNotFound:
+		return http.StatusNotFound
+	case types.ErrWishlistUnauthorized, types.ErrItemUnauthorized, types.ErrTagUnauthorized:
+		return http.StatusUnauthorized
+	case types.ErrCurrentPasswordWrong, types.ErrRegistrationClosed, types.ErrInviteRequired, types.ErrInviteInvalid:
+		return http.StatusForbidden
+	case types.ErrReserveOwnItem, types.ErrItemQuantityReserved, types.ErrItemInvalidCursor, types.ErrEMailConfirm,
+		types.ErrVerifyEMail:
+		return http.StatusBadRequest
+	case types.ErrItemNotEnoughRemaining, types.ErrItemReserveConflict, types.ErrItemNotWanted,
+		types.ErrItemStatusTransition, types.ErrTagNameTaken, types.ErrUsernameTaken, types.ErrEmailTaken:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/go-playground/validator.v9"
+
+	"github.com/jatgam/wishlist-api/config"
+	"github.com/jatgam/wishlist-api/db"
+	authjwt "github.com/jatgam/wishlist-api/jwt"
+	"github.com/jatgam/wishlist-api/migrations"
+	"github.com/jatgam/wishlist-api/models"
+	"github.com/jatgam/wishlist-api/service"
+	"github.com/jatgam/wishlist-api/service/sgmail"
+	"github.com/jatgam/wishlist-api/validation"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logrus.SetOutput(ioutil.Discard)
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("passcomplexity", validation.ComplexityValidator)
+		v.RegisterValidation("notblank", validation.NotBlank)
+		v.RegisterValidation("currency", validation.CurrencyValidator)
+		v.RegisterValidation("price", validation.PriceValidator)
+	}
+	sgmail.SetupMail("", "Jatgam Wishlist", "wishlist@example.com", true)
+	os.Exit(m.Run())
+}
+
+// testAPI serves the V1 routes from a fresh in memory SQLite database.
+type testAPI struct {
+	router *gin.Engine
+	conn   *gorm.DB
+	ginjwt *jwt.GinJWTMiddleware
+	users  models.UserRepository
+	items  models.ItemRepository
+	lists  models.WishlistRepository
+	tags   models.TagRepository
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	logger := logrus.NewEntry(logrus.StandardLogger())
+	conn := db.Connect(&config.DBConfig{Driver: db.DriverSQLite, Database: ":memory:"})
+	if _, err := migrations.Up(conn, logger); err != nil {
+		conn.Close()
+		t.Fatalf("Migrating the test database failed: %s", err.Error())
+	}
+	a := &testAPI{conn: conn, users: models.NewUserRepository(conn), items: models.NewItemRepository(conn),
+		lists: models.NewWishlistRepository(conn), tags: models.NewTagRepository(conn)}
+	svc := service.NewService(a.users, a.items, a.lists, a.tags, models.NewInviteRepository(conn),
+		service.Settings{Secret: []byte("secret"), VerifyWindow: time.Hour, ResendCooldown: time.Minute})
+	a.ginjwt = authjwt.CreateJWTMiddleware("secret", "test", a.users)
+
+	a.router = gin.New()
+	a.router.Use(func(c *gin.Context) { c.Set("ctxLogger", logger) })
+	SetupV1Routes(&a.router.RouterGroup, a.ginjwt, svc)
+	return a
+}
+
+// createUser adds a verified user and returns them with a token to use.
+func (a *testAPI) createUser(t *testing.T, username string) (*models.UserModel, string) {
+	user := &models.UserModel{Username: username, PasswordHash: "unused", EMail: username + "@example.com",
+		FirstName: username, LastName: "Test", UserLevel: 1, EMailVerified: true}
+	if err := a.users.CreateUser(user); err != nil {
+		t.Fatalf("Creating user %s failed: %s", username, err.Error())
+	}
+	token, _, err := a.ginjwt.TokenGenerator(&authjwt.JwtPayload{ID: user.ID, UserLevel: user.UserLevel})
+	if err != nil {
+		t.Fatalf("Creating a token for %s failed: %s", username, err.Error())
+	}
+	return user, token
+}
+
+// do sends the request, form encoding the values if there are any.
+func (a *testAPI) do(method, path, token string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w
+}
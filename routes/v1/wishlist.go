@@ -0,0 +1,291 @@
+package v1
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+
+	"github.com/jatgam/wishlist-api/metrics"
+	"github.com/jatgam/wishlist-api/microservice"
+	"github.com/jatgam/wishlist-api/types"
+)
+
+type addWishlistForm struct {
+	Title        string     `form:"title" binding:"required,notblank,max=255"`
+	Description  string     `form:"description" binding:"max=4096"`
+	Occasion     string     `form:"occasion" binding:"max=255"`
+	OccasionDate *time.Time `form:"occasiondate" time_format:"2006-01-02"`
+	Visibility   string     `form:"visibility" binding:"omitempty,oneof=public registered private"`
+	SurpriseMode bool       `form:"surprise"`
+}
+
+type editWishlistForm struct {
+	Title        *string    `form:"title" binding:"omitempty,notblank,max=255"`
+	Description  *string    `form:"description" binding:"omitempty,max=4096"`
+	Occasion     *string    `form:"occasion" binding:"omitempty,max=255"`
+	OccasionDate *time.Time `form:"occasiondate" time_format:"2006-01-02"`
+	Visibility   *string    `form:"visibility" binding:"omitempty,oneof=public registered private"`
+	SurpriseMode *bool      `form:"surprise"`
+}
+
+type wishlistURI struct {
+	ListID int `uri:"listID" binding:"required,numeric,notblank"`
+}
+
+func (a *api) getWishlists(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	viewerID, admin := getViewer(c)
+	lists, err := a.svc.GetWishlists(viewerID, admin, mylogger)
+	if err != nil {
+		mylogger.Error("Failed to get Wishlists")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListGetError).Inc()
+		types.WriteResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	mylogger.Info("Got Wishlists")
+	types.WriteWishlistsResponse(c, http.StatusOK, "Got a list of wishlists", lists)
+}
+
+func (a *api) getWishlist(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	var listInfo wishlistURI
+	if err := c.ShouldBindUri(&listInfo); err != nil {
+		mylogger.Debug("Get Wishlist Data Validation Error")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	viewerID, admin := getViewer(c)
+	list, err := a.svc.GetWishlist(listInfo.ListID, viewerID, admin, mylogger)
+	if err != nil {
+		mylogger.Error("Failed to get Wishlist")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListGetError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Got Wishlist")
+	types.WriteWishlistResponse(c, http.StatusOK, "Got the wishlist", list)
+}
+
+func (a *api) addWishlist(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to AddWishlist: %s", err.Error())
+		metrics.WishlistErrors.WithLabelValues(metrics.ListAddError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var newList addWishlistForm
+	if err := c.ShouldBind(&newList); err != nil {
+		mylogger.Debug("addWishlist Failed Form Data Validation")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	details := types.WishlistDetails{Description: strings.TrimSpace(newList.Description),
+		Occasion: strings.TrimSpace(newList.Occasion), OccasionDate: newList.OccasionDate,
+		Visibility: newList.Visibility, SurpriseMode: newList.SurpriseMode}
+	if err := a.svc.AddWishlist(userID, strings.TrimSpace(newList.Title), details, mylogger); err != nil {
+		mylogger.Error("Wishlist Add Failed.")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListAddError).Inc()
+		types.WriteResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	mylogger.Info("Wishlist Created")
+	types.WriteResponse(c, http.StatusOK, "Wishlist Created.")
+}
+
+func (a *api) editWishlist(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to EditWishlist: %s", err.Error())
+		metrics.WishlistErrors.WithLabelValues(metrics.ListEditError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var listInfo wishlistURI
+	if err := c.ShouldBindUri(&listInfo); err != nil {
+		mylogger.Debug("Edit Wishlist Data Validation Error")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	var listEdit editWishlistForm
+	if err := c.ShouldBind(&listEdit); err != nil {
+		mylogger.Debug("editWishlist Failed Form Data Validation")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	edit := types.WishlistEdit{Title: trimmedOrNil(listEdit.Title), Description: trimmedOrNil(listEdit.Description),
+		Occasion: trimmedOrNil(listEdit.Occasion), OccasionDate: listEdit.OccasionDate,
+		Visibility: listEdit.Visibility, SurpriseMode: listEdit.SurpriseMode}
+	// An empty occasiondate binds as the zero time, and clears the date.
+	if edit.OccasionDate != nil && edit.OccasionDate.IsZero() {
+		edit.OccasionDate, edit.ClearOccasionDate = nil, true
+	}
+	err = a.svc.EditWishlist(listInfo.ListID, userID, isAdmin(c), edit, mylogger)
+	if err != nil {
+		mylogger.Error("Failed to Edit Wishlist")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListEditError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Wishlist Edited")
+	types.WriteResponse(c, http.StatusOK, "Wishlist Updated")
+}
+
+func (a *api) deleteWishlist(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to DeleteWishlist: %s", err.Error())
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDeleteError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var listInfo wishlistURI
+	if err := c.ShouldBindUri(&listInfo); err != nil {
+		mylogger.Debug("Delete Wishlist Data Validation Error")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := a.svc.DeleteWishlist(listInfo.ListID, userID, isAdmin(c), mylogger); err != nil {
+		mylogger.Error("Failed to Delete Wishlist")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDeleteError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Wishlist Deleted")
+	types.WriteResponse(c, http.StatusOK, "Wishlist Deleted")
+}
+
+func (a *api) getWishlistWantedItems(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	var listInfo wishlistURI
+	if err := c.ShouldBindUri(&listInfo); err != nil {
+		mylogger.Debug("Get Wishlist Items Data Validation Error")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	query, ok := bindItemListQuery(c)
+	if !ok {
+		return
+	}
+
+	viewerID, admin := getViewer(c)
+	page, err := a.svc.GetWishlistWantedItems(listInfo.ListID, viewerID, admin, query, mylogger)
+	if err != nil {
+		mylogger.Error("Failed to get Wishlist Wanted Items")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemGetError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Got Wishlist Wanted Items")
+	types.WriteItemPageResponse(c, http.StatusOK, "Got a list of Wanted items", page)
+}
+
+func (a *api) getWishlistAllItems(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to get wishlist items: %s", err.Error())
+		metrics.ItemErrors.WithLabelValues(metrics.ItemGetError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var listInfo wishlistURI
+	if err := c.ShouldBindUri(&listInfo); err != nil {
+		mylogger.Debug("Get Wishlist Items Data Validation Error")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	query, ok := bindItemListQuery(c)
+	if !ok {
+		return
+	}
+
+	page, err := a.svc.GetWishlistAllItems(listInfo.ListID, userID, isAdmin(c), query, mylogger)
+	if err != nil {
+		mylogger.Error("Failed to get Wishlist All Items")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemGetError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Got Wishlist All Items")
+	types.WriteItemPageResponse(c, http.StatusOK, "Got a list of items", page)
+}
+
+func (a *api) getWishlistReservedItems(c *gin.Context) {
+	mylogger := microservice.GetLogger(c)
+	userID, err := getAuthenticatedUsersID(c)
+	if err != nil {
+		mylogger.Errorf("Failed to get wishlist reserved items: %s", err.Error())
+		metrics.ItemErrors.WithLabelValues(metrics.ItemGetError).Inc()
+		types.WriteResponse(c, http.StatusUnauthorized, "Unauthorized Access")
+		return
+	}
+
+	var listInfo wishlistURI
+	if err := c.ShouldBindUri(&listInfo); err != nil {
+		mylogger.Debug("Get Wishlist Items Data Validation Error")
+		metrics.WishlistErrors.WithLabelValues(metrics.ListDataValidationError).Inc()
+		types.WriteResponse(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	query, ok := bindItemListQuery(c)
+	if !ok {
+		return
+	}
+
+	page, err := a.svc.GetWishlistReservedItems(listInfo.ListID, userID, isAdmin(c), query, mylogger)
+	if err != nil {
+		mylogger.Error("Failed to get Wishlist Reserved Items")
+		metrics.ItemErrors.WithLabelValues(metrics.ItemGetError).Inc()
+		types.WriteResponse(c, serviceErrorStatus(err), err.Error())
+		return
+	}
+
+	mylogger.Info("Got Wishlist Reserved Items")
+	types.WriteItemPageResponse(c, http.StatusOK, "Got a list of reserved items", page)
+}
+
+func (a *api) setupWishlistRoutes(router *gin.RouterGroup, ginjwt *jwt.GinJWTMiddleware) {
+	authMiddleware := ginjwt.MiddlewareFunc()
+	optionalAuth := optionalAuthMiddleware(ginjwt)
+	router.GET("", optionalAuth, a.getWishlists)
+	router.POST("", authMiddleware, a.addWishlist)
+	router.GET("/id/:listID", optionalAuth, a.getWishlist)
+	router.PATCH("/id/:listID", authMiddleware, a.editWishlist)
+	router.DELETE("/id/:listID", authMiddleware, a.deleteWishlist)
+	router.GET("/id/:listID/items", optionalAuth, a.getWishlistWantedItems)
+	router.GET("/id/:listID/items/all", authMiddleware, a.getWishlistAllItems)
+	router.GET("/id/:listID/items/reserved", authMiddleware, a.getWishlistReservedItems)
+	router.GET("/id/:listID/export", optionalAuth, a.exportWishlistItems)
+}
@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jatgam/wishlist-api/models"
+	"github.com/jatgam/wishlist-api/types"
+)
+
+func (a *testAPI) getItems(t *testing.T, path, token string) types.GetItemsResponse {
+	w := a.do(http.MethodGet, path, token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET %s returned %v: %s", path, w.Code, w.Body.String())
+	}
+	var resp types.GetItemsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("GET %s returned bad json: %s", path, err.Error())
+	}
+	return resp
+}
+
+func itemNames(resp types.GetItemsResponse) []string {
+	names := []string{}
+	for _, item := range *resp.Items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+func TestWishlistItemsPaging(t *testing.T) {
+	a := newTestAPI(t)
+	defer a.conn.Close()
+
+	owner, token := a.createUser(t, "owner")
+	list := &models.WishlistModel{OwnerID: owner.ID, Title: "Birthday"}
+	if err := a.lists.CreateWishlist(list); err != nil {
+		t.Fatalf("Creating the wishlist failed: %s", err.Error())
+	}
+	for i, name := range []string{"Book", "Scarf", "Boots"} {
+		price := float64(10 * (i + 1))
+		item := &models.ItemModel{Name: name, URL: "https://example.com/" + name, OwnerID: &owner.ID,
+			WishlistID: &list.ID, Rank: i + 1, Price: &price, Quantity: 1}
+		if err := a.items.AddItem(item); err != nil {
+			t.Fatalf("Adding item %s failed: %s", name, err.Error())
+		}
+	}
+
+	path := fmt.Sprintf("/list/id/%d/items", list.ID)
+	first := a.getItems(t, path+"?limit=2", "")
+	if got := itemNames(first); len(got) != 2 || got[0] != "Book" || got[1] != "Scarf" {
+		t.Errorf("First page is %v, want [Book Scarf]", got)
+	}
+	if first.Total == nil || *first.Total != 3 || first.NextCursor == "" {
+		t.Fatalf("First page has total %v and cursor %q, want 3 and a cursor", first.Total, first.NextCursor)
+	}
+	second := a.getItems(t, path+"?limit=2&cursor="+first.NextCursor, "")
+	if got := itemNames(second); len(got) != 1 || got[0] != "Boots" || second.NextCursor != "" {
+		t.Errorf("Second page is %v with cursor %q, want [Boots] and no cursor", got, second.NextCursor)
+	}
+
+	filtered := a.getItems(t, fmt.Sprintf("/list/id/%d/items/all?name=bo&sort=price&order=desc", list.ID), token)
+	if got := itemNames(filtered); len(got) != 2 || got[0] != "Boots" || got[1] != "Book" {
+		t.Errorf("Filtered items are %v, want [Boots Book]", got)
+	}
+
+	if w := a.do(http.MethodGet, path+"?cursor=!", "", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("A bad cursor returned %v, want %v", w.Code, http.StatusBadRequest)
+	}
+	if w := a.do(http.MethodGet, path+"?limit=101", "", nil); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("A bad limit returned %v, want %v", w.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestWishlistItemsTagFilter(t *testing.T) {
+	a := newTestAPI(t)
+	defer a.conn.Close()
+
+	owner, token := a.createUser(t, "owner")
+	list := &models.WishlistModel{OwnerID: owner.ID, Title: "Birthday"}
+	if err := a.lists.CreateWishlist(list); err != nil {
+		t.Fatalf("Creating the wishlist failed: %s", err.Error())
+	}
+	tag := &models.TagModel{OwnerID: owner.ID, Name: "Books"}
+	if err := a.tags.CreateTag(tag); err != nil {
+		t.Fatalf("Creating the tag failed: %s", err.Error())
+	}
+	for _, name := range []string{"Novel", "Scarf", "Atlas"} {
+		item := &models.ItemModel{Name: name, URL: "https://example.com/" + name, OwnerID: &owner.ID,
+			WishlistID: &list.ID, Quantity: 1}
+		if err := a.items.AddItem(item); err != nil {
+			t.Fatalf("Adding item %s failed: %s", name, err.Error())
+		}
+		if name == "Scarf" {
+			continue
+		}
+		if err := a.tags.TagItem(item, tag); err != nil {
+			t.Fatalf("Tagging item %s failed: %s", name, err.Error())
+		}
+	}
+
+	for _, path := range []string{"/list/id/%d/items?tag=books", "/list/id/%d/items/all?tag=books"} {
+		resp := a.getItems(t, fmt.Sprintf(path, list.ID), token)
+		if got := itemNames(resp); len(got) != 2 || got[0] != "Atlas" || got[1] != "Novel" {
+			t.Errorf("%s returned %v, want [Atlas Novel]", path, got)
+		}
+	}
+}
+
+func TestEditWishlistOccasionDate(t *testing.T) {
+	a := newTestAPI(t)
+	defer a.conn.Close()
+
+	owner, token := a.createUser(t, "owner")
+	list := &models.WishlistModel{OwnerID: owner.ID, Title: "Birthday"}
+	if err := a.lists.CreateWishlist(list); err != nil {
+		t.Fatalf("Creating the wishlist failed: %s", err.Error())
+	}
+	path := fmt.Sprintf("/list/id/%d", list.ID)
+
+	for _, test := range []struct {
+		form url.Values
+		want string
+	}{
+		{url.Values{"occasiondate": {"2030-05-01"}}, "2030-05-01"},
+		{url.Values{"title": {"Party"}}, "2030-05-01"},
+		{url.Values{"occasiondate": {""}}, ""},
+	} {
+		if w := a.do(http.MethodPatch, path, token, test.form); w.Code != http.StatusOK {
+			t.Fatalf("PATCH %v returned %v: %s", test.form, w.Code, w.Body.String())
+		}
+		found, _ := a.lists.FindWishlist(list.ID)
+		got := ""
+		if found.OccasionDate != nil {
+			got = found.OccasionDate.Format("2006-01-02")
+		}
+		if got != test.want {
+			t.Errorf("After PATCH %v the occasion date is %q, want %q", test.form, got, test.want)
+		}
+	}
+}
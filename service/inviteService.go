@@ -0,0 +1,70 @@
+package service
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jatgam/wishlist-api/models"
+	"github.com/jatgam/wishlist-api/types"
+	"github.com/jatgam/wishlist-api/utils"
+)
+
+// GetInvites returns every registration invite, the newest first.
+func (s *Service) GetInvites(logger *logrus.Entry) (*[]types.Invite, error) {
+	invites, err := s.invites.GetInvites()
+	if err != nil {
+		logger.Errorf("GetInvites: Failed DB Query: %s", err.Error())
+		return nil, types.ErrGetInvitesDB
+	}
+	logger.Infof("Got %v invites.", len(*invites))
+	resp := []types.Invite{}
+	for _, invite := range *invites {
+		resp = append(resp, inviteDBModelToResponse(invite))
+	}
+	return &resp, nil
+}
+
+// AddInvite mints a registration invite that can be used maxUses times. It
+// never expires when expiresIn is 0.
+func (s *Service) AddInvite(creatorID, maxUses int, expiresIn time.Duration, logger *logrus.Entry) (*types.Invite, error) {
+	code, err := utils.RandomHex(8)
+	if err != nil {
+		logger.Errorf("AddInvite: Failed to Generate Code: %s", err.Error())
+		return nil, types.ErrAddInvite
+	}
+	invite := &models.InviteModel{Code: code, CreatorID: creatorID, MaxUses: maxUses}
+	if expiresIn > 0 {
+		expiresAt := time.Now().Add(expiresIn)
+		invite.ExpiresAt = &expiresAt
+	}
+	if err := s.invites.CreateInvite(invite); err != nil {
+		logger.Errorf("Failed to Add Invite: %s", err.Error())
+		return nil, types.ErrAddInvite
+	}
+	resp := inviteDBModelToResponse(*invite)
+	return &resp, nil
+}
+
+// DeleteInvite revokes an invite, so its code can't be used to register.
+func (s *Service) DeleteInvite(inviteID int, logger *logrus.Entry) error {
+	invite, err := s.invites.FindInvite(inviteID)
+	if err != nil {
+		logger.Errorf("DeleteInvite: Failed DB Query: %s", err.Error())
+		return types.ErrDeleteInvite
+	}
+	if invite == nil {
+		logger.Debugf("No invite found with ID: %v", inviteID)
+		return types.ErrInviteNotFound
+	}
+	if err := s.invites.DeleteInvite(invite); err != nil {
+		logger.Errorf("Failed to Delete Invite: %s", err.Error())
+		return types.ErrDeleteInvite
+	}
+	return nil
+}
+
+func inviteDBModelToResponse(invite models.InviteModel) types.Invite {
+	return types.Invite{ID: invite.ID, Code: invite.Code, CreatorID: invite.CreatorID, MaxUses: invite.MaxUses,
+		Uses: invite.Uses, ExpiresAt: invite.ExpiresAt, CreatedAt: invite.CreatedAt, UpdatedAt: invite.UpdatedAt}
+}
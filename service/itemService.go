@@ -1,148 +1,727 @@
 package service
 
 import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/sirupsen/logrus"
 
 	"github.com/jatgam/wishlist-api/models"
+	"github.com/jatgam/wishlist-api/service/sgmail"
 	"github.com/jatgam/wishlist-api/types"
 )
 
-func GetWantedItems(logger *logrus.Entry) (*[]types.Items, error) {
-	wantedItems, err := models.GetWantedItems()
+func (s *Service) GetWantedItems(query types.ItemQuery, logger *logrus.Entry) (*types.ItemPage, error) {
+	opts, err := itemListOptions(query)
+	if err != nil {
+		logger.Errorf("GetWantedItems: Bad cursor %q", query.Cursor)
+		return nil, err
+	}
+	wantedItems, total, err := s.items.GetWantedItems(opts)
 	if err != nil {
 		logger.Errorf("GetWantedItems: Failed DB Query: %s", err.Error())
 		return nil, types.ErrGetWantedItemsDB
 	}
-	if wantedItems != nil {
-		logger.Infof("Got %v wanted items.", len(*wantedItems))
-	} else {
-		logger.Info("Got 0 wanted items.")
-	}
+	logger.Infof("Got %v of %v wanted items.", len(*wantedItems), total)
 
-	return itemDBModelToResponse(wantedItems), nil
+	return itemPage(itemDBModelToResponse(wantedItems, nil), total, opts), nil
 }
 
-func GetAllItems(logger *logrus.Entry) (*[]types.Items, error) {
-	allItems, err := models.GetAllItems()
+// GetAllItems returns every item. Reservations on the viewers own surprise
+// wishlists are hidden.
+func (s *Service) GetAllItems(viewerID int, query types.ItemQuery, logger *logrus.Entry) (*types.ItemPage, error) {
+	opts, err := itemListOptions(query)
+	if err != nil {
+		logger.Errorf("GetAllItems: Bad cursor %q", query.Cursor)
+		return nil, err
+	}
+	hiddenLists, err := s.hiddenReservationLists(viewerID, logger)
+	if err != nil {
+		return nil, err
+	}
+	allItems, total, err := s.items.GetAllItems(opts)
 	if err != nil {
 		logger.Errorf("GetAllItems: Failed DB Query: %s", err.Error())
 		return nil, types.ErrGetAllItemsDB
 	}
-	if allItems != nil {
-		logger.Infof("Got %v items", len(*allItems))
-	} else {
-		logger.Info("Got 0 items")
-	}
-	return itemDBModelToResponse(allItems), nil
+	logger.Infof("Got %v of %v items", len(*allItems), total)
+	return itemPage(itemDBModelToResponse(allItems, hiddenLists), total, opts), nil
 }
 
-func GetReservedItems(userID int, logger *logrus.Entry) (*[]types.Items, error) {
-	reservedItems, err := models.GetReservedItems(userID)
+func (s *Service) GetReservedItems(userID int, query types.ItemQuery, logger *logrus.Entry) (*types.ItemPage, error) {
+	opts, err := itemListOptions(query)
+	if err != nil {
+		logger.Errorf("GetReservedItems: Bad cursor %q", query.Cursor)
+		return nil, err
+	}
+	reservedItems, total, err := s.items.GetReservedItems(userID, opts)
 	if err != nil {
 		logger.Errorf("GetReservedItems: Failed DB Query: %s", err.Error())
 		return nil, types.ErrGetReservedItemsDB
 	}
-	if reservedItems != nil {
-		logger.Infof("Got %v items", len(*reservedItems))
+	logger.Infof("Got %v of %v items", len(*reservedItems), total)
+	items, err := s.withUsersReservations(itemDBModelToResponse(reservedItems, nil), userID, logger)
+	if err != nil {
+		return nil, err
+	}
+	return itemPage(items, total, opts), nil
+}
+
+// itemListOptions converts the listing query for the models. The cursor is
+// the offset of the next page, encoded so clients treat it as opaque.
+func itemListOptions(query types.ItemQuery) (models.ItemListOptions, error) {
+	opts := models.ItemListOptions{Status: query.Status, MinPrice: query.MinPrice, MaxPrice: query.MaxPrice,
+		Name: query.Name, Tag: query.Tag, Sort: query.Sort, Desc: query.Desc, Limit: query.Limit}
+	if query.Cursor == "" {
+		return opts, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return opts, types.ErrItemInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(decoded))
+	if err != nil || offset < 0 {
+		return opts, types.ErrItemInvalidCursor
+	}
+	opts.Offset = offset
+	return opts, nil
+}
+
+func itemPage(items *[]types.Items, total int, opts models.ItemListOptions) *types.ItemPage {
+	page := &types.ItemPage{Items: items, Total: total}
+	next := opts.Offset + len(*items)
+	if opts.Limit > 0 && next < total {
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(next)))
+	}
+	return page
+}
+
+// GetWishlistWantedItems returns the items on the list that aren't fully
+// reserved. When the list is in surprise mode the owner also gets the reserved
+// and purchased items, shown as unreserved, so they don't give the surprise
+// away by vanishing.
+func (s *Service) GetWishlistWantedItems(listID, viewerID int, isAdmin bool, query types.ItemQuery, logger *logrus.Entry) (*types.ItemPage, error) {
+	opts, err := itemListOptions(query)
+	if err != nil {
+		logger.Errorf("GetWishlistWantedItems: Bad cursor %q", query.Cursor)
+		return nil, err
+	}
+	list, err := s.findViewableWishlist(listID, viewerID, isAdmin, logger)
+	if err != nil {
+		return nil, err
+	}
+	hideReservations := surpriseActive(list, viewerID)
+	var wantedItems *[]models.ItemModel
+	var total int
+	if hideReservations {
+		wantedItems, total, err = s.getSurpriseItems(listID, opts, true)
 	} else {
-		logger.Info("Got 0 items")
+		wantedItems, total, err = s.items.GetWishlistWantedItems(listID, opts)
 	}
-	return itemDBModelToResponse(reservedItems), nil
+	if err != nil {
+		logger.Errorf("GetWishlistWantedItems: Failed DB Query: %s", err.Error())
+		return nil, types.ErrGetWantedItemsDB
+	}
+	logger.Infof("Got %v of %v wanted items for wishlist %v.", len(*wantedItems), total, listID)
+	return itemPage(itemDBModelToResponse(wantedItems, map[int]bool{listID: hideReservations}), total, opts), nil
 }
 
-func EditItemRank(itemID int, rank int, logger *logrus.Entry) error {
-	item, err := models.FindOneItem(map[string]interface{}{"id": itemID})
+func (s *Service) GetWishlistAllItems(listID, userID int, isAdmin bool, query types.ItemQuery, logger *logrus.Entry) (*types.ItemPage, error) {
+	opts, err := itemListOptions(query)
 	if err != nil {
-		logger.Errorf("EditItemRank: Failed DB Query to find item: %s", err.Error())
-		return types.ErrEditItem
+		logger.Errorf("GetWishlistAllItems: Bad cursor %q", query.Cursor)
+		return nil, err
 	}
-	if item == nil {
-		logger.Error("EditItemRank: Item Doesn't Exist")
-		return types.ErrEditItem
+	list, err := s.findManagedWishlist(listID, userID, isAdmin, logger)
+	if err != nil {
+		return nil, err
+	}
+	hideReservations := surpriseActive(list, userID)
+	var allItems *[]models.ItemModel
+	var total int
+	if hideReservations {
+		allItems, total, err = s.getSurpriseItems(listID, opts, false)
+	} else {
+		allItems, total, err = s.items.GetWishlistAllItems(listID, opts)
+	}
+	if err != nil {
+		logger.Errorf("GetWishlistAllItems: Failed DB Query: %s", err.Error())
+		return nil, types.ErrGetAllItemsDB
+	}
+	logger.Infof("Got %v of %v items for wishlist %v", len(*allItems), total, listID)
+	return itemPage(itemDBModelToResponse(allItems, map[int]bool{listID: hideReservations}), total, opts), nil
+}
+
+// getSurpriseItems lists the items of a surprise wishlist as the owner sees
+// them, where reserved and purchased items show as wanted. The status filter
+// matches the status shown, so it can't single out the reserved items. With
+// activeOnly the received and archived items are left out.
+func (s *Service) getSurpriseItems(listID int, opts models.ItemListOptions, activeOnly bool) (*[]models.ItemModel, int, error) {
+	switch {
+	case opts.Status == models.ItemStatusReserved || opts.Status == models.ItemStatusPurchased:
+		return &[]models.ItemModel{}, 0, nil
+	case opts.Status == models.ItemStatusWanted || (opts.Status == "" && activeOnly):
+		opts.Status = ""
+		return s.items.GetWishlistActiveItems(listID, opts)
+	case activeOnly:
+		return &[]models.ItemModel{}, 0, nil
+	default:
+		return s.items.GetWishlistAllItems(listID, opts)
+	}
+}
+
+func (s *Service) GetWishlistReservedItems(listID, userID int, isAdmin bool, query types.ItemQuery, logger *logrus.Entry) (*types.ItemPage, error) {
+	opts, err := itemListOptions(query)
+	if err != nil {
+		logger.Errorf("GetWishlistReservedItems: Bad cursor %q", query.Cursor)
+		return nil, err
+	}
+	if _, err := s.findViewableWishlist(listID, userID, isAdmin, logger); err != nil {
+		return nil, err
+	}
+	reservedItems, total, err := s.items.GetWishlistReservedItems(listID, userID, opts)
+	if err != nil {
+		logger.Errorf("GetWishlistReservedItems: Failed DB Query: %s", err.Error())
+		return nil, types.ErrGetReservedItemsDB
+	}
+	logger.Infof("Got %v of %v reserved items for wishlist %v", len(*reservedItems), total, listID)
+	items, err := s.withUsersReservations(itemDBModelToResponse(reservedItems, nil), userID, logger)
+	if err != nil {
+		return nil, err
+	}
+	return itemPage(items, total, opts), nil
+}
+
+func (s *Service) EditItemRank(itemID, rank, userID int, isAdmin bool, logger *logrus.Entry) error {
+	item, err := s.findManagedItem(itemID, userID, isAdmin, logger)
+	if err != nil {
+		logger.Error("EditItemRank: Failed to find item")
+		return err
 	}
-	updateErr := models.UpdateItemWithMap(item, map[string]interface{}{"rank": rank})
+	updateErr := s.items.UpdateItem(item, map[string]interface{}{"rank": rank})
 	if updateErr != nil {
-		logger.Errorf("EditItemRank: Failed DB Query to update item: %s", err.Error())
+		logger.Errorf("EditItemRank: Failed DB Query to update item: %s", updateErr.Error())
 		return types.ErrEditItem
 	}
 	return nil
 }
 
-func ReserveItem(userID, itemID int, logger *logrus.Entry) error {
-	item, err := models.FindOneItem(map[string]interface{}{"id": itemID, "reserved": false}, models.ItemReserveScope)
+// EditItem updates only the fields that are not nil.
+func (s *Service) EditItem(itemID, userID int, isAdmin bool, edit types.ItemEdit, logger *logrus.Entry) error {
+	item, err := s.findManagedItem(itemID, userID, isAdmin, logger)
 	if err != nil {
-		logger.Errorf("ReserveItem: Failed DB Query to find item: %s", err.Error())
-		return types.ErrEditItem
+		logger.Error("EditItem: Failed to find item")
+		return err
 	}
-	if item == nil {
-		logger.Error("ReserveItem: Item Doesn't Exist, or already reserved")
-		return types.ErrEditItem
+	updates := map[string]interface{}{}
+	if edit.Name != nil {
+		updates["name"] = *edit.Name
+	}
+	if edit.URL != nil {
+		updates["url"] = *edit.URL
+	}
+	if edit.Rank != nil {
+		updates["rank"] = *edit.Rank
+	}
+	if edit.Price != nil {
+		updates["price"] = *edit.Price
+	}
+	if edit.Currency != nil {
+		updates["currency"] = strings.ToUpper(*edit.Currency)
+	}
+	quantity := item.Quantity
+	if edit.Quantity != nil {
+		quantity = *edit.Quantity
+		if quantity < item.ReservedQuantity {
+			if !s.reservationsHidden(item, userID, logger) {
+				logger.Errorf("EditItem: Quantity %v is less than the %v reserved", quantity, item.ReservedQuantity)
+				return types.ErrItemQuantityReserved
+			}
+			// Refusing would tell the owner the item is reserved, so keep
+			// enough to cover the reservations instead.
+			logger.Infof("EditItem: Quantity %v raised to the %v reserved on a surprise wishlist", quantity, item.ReservedQuantity)
+			quantity = item.ReservedQuantity
+		}
+	}
+	if edit.Notes != nil {
+		updates["notes"] = *edit.Notes
+	}
+	if edit.ImageURL != nil {
+		updates["imageurl"] = *edit.ImageURL
+	}
+	if edit.Size != nil {
+		updates["size"] = *edit.Size
+	}
+	if edit.Color != nil {
+		updates["color"] = *edit.Color
 	}
-	updateErr := models.UpdateItemWithMap(item, map[string]interface{}{"reserverid": userID, "reserved": true})
+	if len(updates) == 0 && edit.Quantity == nil {
+		logger.Debug("EditItem: Nothing to update")
+		return nil
+	}
+	if edit.Quantity == nil {
+		if updateErr := s.items.UpdateItem(item, updates); updateErr != nil {
+			logger.Errorf("EditItem: Failed DB Query to update item: %s", updateErr.Error())
+			return types.ErrEditItem
+		}
+		return nil
+	}
+	// The quantity is only changed while it covers the reservations, which
+	// may have grown since the item was read.
+	updated, updateErr := s.items.UpdateItemQuantity(item, quantity, updates)
 	if updateErr != nil {
-		logger.Errorf("ReserveItem: Failed DB Query to update item: %s", err.Error())
+		logger.Errorf("EditItem: Failed DB Query to update item: %s", updateErr.Error())
 		return types.ErrEditItem
 	}
+	if !updated {
+		logger.Errorf("EditItem: Quantity %v is less than a concurrent reservation", quantity)
+		return types.ErrItemReserveConflict
+	}
 	return nil
 }
 
-func UnReserveItem(userID, itemID int, logger *logrus.Entry) error {
-	item, err := models.FindOneItem(map[string]interface{}{"id": itemID}, models.ItemReserveScope)
+// ReserveItem reserves quantity of the item for the user. An item can be
+// reserved by several users until its full quantity is claimed.
+func (s *Service) ReserveItem(userID, itemID, quantity int, isAdmin bool, logger *logrus.Entry) error {
+	item, err := s.findItem(itemID, false, logger)
 	if err != nil {
-		logger.Errorf("UnReserveItem: Failed DB Query to find item: %s", err.Error())
+		logger.Error("ReserveItem: Failed to find item")
+		return err
+	}
+	if item.WishlistID != nil {
+		if _, err := s.findViewableWishlist(*item.WishlistID, userID, isAdmin, logger); err != nil {
+			logger.Error("ReserveItem: Item is on a wishlist the user can't view")
+			return types.ErrItemNotFound
+		}
+	}
+	if item.OwnerID != nil && *item.OwnerID == userID {
+		logger.Error("ReserveItem: Users can't reserve their own items")
+		return types.ErrReserveOwnItem
+	}
+	if item.Status != models.ItemStatusWanted && item.Status != models.ItemStatusReserved {
+		logger.Errorf("ReserveItem: Item %v is %v", itemID, item.Status)
+		return types.ErrItemNotWanted
+	}
+	if remaining := item.Quantity - item.ReservedQuantity; quantity > remaining {
+		logger.Errorf("ReserveItem: Wanted to reserve %v, only %v remaining", quantity, remaining)
+		return types.ErrItemNotEnoughRemaining
+	}
+	reserved, reserveErr := s.items.ReserveItem(item, userID, quantity)
+	if reserveErr != nil {
+		logger.Errorf("ReserveItem: Failed DB Query to reserve item: %s", reserveErr.Error())
 		return types.ErrEditItem
 	}
-	if item == nil {
-		logger.Error("UnReserveItem: Item Doesn't Exist")
+	if !reserved {
+		logger.Errorf("ReserveItem: Item %v was reserved by someone else first", itemID)
+		return types.ErrItemReserveConflict
+	}
+	return nil
+}
+
+// UnReserveItem releases the users reservation of the item. Reservations held
+// by other users are left in place.
+func (s *Service) UnReserveItem(userID, itemID int, logger *logrus.Entry) error {
+	item, err := s.findItem(itemID, true, logger)
+	if err != nil {
+		logger.Error("UnReserveItem: Failed to find item")
+		return err
+	}
+	reservation, err := s.items.FindReservation(item.ID, userID)
+	if err != nil {
+		logger.Errorf("UnReserveItem: Failed DB Query to find reservation: %s", err.Error())
 		return types.ErrEditItem
 	}
-	if item.ReserverID == nil || *item.ReserverID != userID {
-		logger.Errorf("UnReserveItem: Failed, not original reserver or admin")
+	if reservation == nil {
+		logger.Errorf("UnReserveItem: Failed, user %v has no reservation", userID)
+		return types.ErrItemUnauthorized
+	}
+	// Deleted items are still released, so they don't come back reserved if
+	// the owner restores them.
+	if item.DeletedAt == nil && item.Status != models.ItemStatusWanted && item.Status != models.ItemStatusReserved {
+		logger.Errorf("UnReserveItem: Item %v is already %v", itemID, item.Status)
+		return types.ErrItemStatusTransition
+	}
+	released, unreserveErr := s.items.UnReserveItem(item, reservation)
+	if unreserveErr != nil {
+		logger.Errorf("UnReserveItem: Failed DB Query to unreserve item: %s", unreserveErr.Error())
 		return types.ErrEditItem
 	}
-	updateErr := models.UpdateItemWithMap(item, map[string]interface{}{"reserverid": nil, "reserved": false})
-	if updateErr != nil {
-		logger.Errorf("UnReserveItem: Failed DB Query to update item: %s", err.Error())
+	if !released {
+		logger.Errorf("UnReserveItem: Reservation for item %v changed while releasing it", itemID)
+		return types.ErrItemReserveConflict
+	}
+	return nil
+}
+
+// itemStatusTransitions are the legal status changes that can be made directly.
+// Moving between wanted and reserved only happens through reservations.
+var itemStatusTransitions = map[string][]string{
+	models.ItemStatusWanted:    {models.ItemStatusReceived, models.ItemStatusArchived},
+	models.ItemStatusReserved:  {models.ItemStatusPurchased, models.ItemStatusReceived, models.ItemStatusArchived},
+	models.ItemStatusPurchased: {models.ItemStatusReserved, models.ItemStatusReceived, models.ItemStatusArchived},
+	models.ItemStatusReceived:  {models.ItemStatusArchived},
+	models.ItemStatusArchived:  {models.ItemStatusWanted, models.ItemStatusReserved},
+}
+
+func canTransitionItem(from, to string) bool {
+	for _, status := range itemStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionItem moves the item to the new status if the change is legal.
+func (s *Service) transitionItem(item *models.ItemModel, to string, logger *logrus.Entry) error {
+	if !canTransitionItem(item.Status, to) {
+		logger.Errorf("Item %v can't move from %v to %v", item.ID, item.Status, to)
+		return types.ErrItemStatusTransition
+	}
+	updated, err := s.items.UpdateItemStatus(item, item.Status, to)
+	if err != nil {
+		logger.Errorf("Failed DB Query to update item status: %s", err.Error())
 		return types.ErrEditItem
 	}
+	if !updated {
+		logger.Errorf("Item %v status changed before it could move to %v", item.ID, to)
+		return types.ErrItemStatusTransition
+	}
 	return nil
 }
 
-func AddItem(name, url string, rank int, logger *logrus.Entry) error {
-	if err := models.AddItem(name, url, rank); err != nil {
+// PurchaseItem lets a reserver mark a fully reserved item as purchased. When
+// several users share the reservations any one of them can mark it, as the
+// item is bought once for all of them, and any of them can undo it.
+func (s *Service) PurchaseItem(userID, itemID int, logger *logrus.Entry) error {
+	item, err := s.findReservedItem(userID, itemID, logger)
+	if err != nil {
+		logger.Error("PurchaseItem: Failed to find item")
+		return err
+	}
+	return s.transitionItem(item, models.ItemStatusPurchased, logger)
+}
+
+// UnPurchaseItem lets a reserver move a purchased item back to reserved.
+func (s *Service) UnPurchaseItem(userID, itemID int, logger *logrus.Entry) error {
+	item, err := s.findReservedItem(userID, itemID, logger)
+	if err != nil {
+		logger.Error("UnPurchaseItem: Failed to find item")
+		return err
+	}
+	if item.Status != models.ItemStatusPurchased {
+		logger.Errorf("UnPurchaseItem: Item %v is %v", itemID, item.Status)
+		return types.ErrItemStatusTransition
+	}
+	return s.transitionItem(item, models.ItemStatusReserved, logger)
+}
+
+// ReceiveItem lets the owner mark an item as received, removing it from the
+// wanted items.
+func (s *Service) ReceiveItem(itemID, userID int, isAdmin bool, logger *logrus.Entry) error {
+	item, err := s.findManagedItem(itemID, userID, isAdmin, logger)
+	if err != nil {
+		logger.Error("ReceiveItem: Failed to find item")
+		return err
+	}
+	return s.transitionItem(item, models.ItemStatusReceived, logger)
+}
+
+// ArchiveItem lets the owner hide an item from the wanted items without
+// deleting it.
+func (s *Service) ArchiveItem(itemID, userID int, isAdmin bool, logger *logrus.Entry) error {
+	item, err := s.findManagedItem(itemID, userID, isAdmin, logger)
+	if err != nil {
+		logger.Error("ArchiveItem: Failed to find item")
+		return err
+	}
+	return s.transitionItem(item, models.ItemStatusArchived, logger)
+}
+
+// RestoreItem brings back an archived or deleted item. Archived items go back
+// to wanted, or reserved if their reservations still cover the quantity.
+func (s *Service) RestoreItem(itemID, userID int, isAdmin bool, logger *logrus.Entry) error {
+	item, err := s.findItem(itemID, true, logger)
+	if err == nil && !canManageItem(item, userID, isAdmin) {
+		logger.Debugf("User %v does not own item %v", userID, itemID)
+		err = types.ErrItemUnauthorized
+	}
+	if err != nil {
+		logger.Error("RestoreItem: Failed to find item")
+		return err
+	}
+	if item.DeletedAt != nil {
+		if item.WishlistID != nil {
+			if _, err := s.findWishlist(*item.WishlistID, logger); err != nil {
+				logger.Errorf("RestoreItem: Wishlist of item %v is gone", itemID)
+				return err
+			}
+		}
+		if restoreErr := s.items.RestoreItem(item); restoreErr != nil {
+			logger.Errorf("RestoreItem: Failed DB Query to restore item: %s", restoreErr.Error())
+			return types.ErrEditItem
+		}
+		return nil
+	}
+	if item.Status != models.ItemStatusArchived {
+		logger.Errorf("RestoreItem: Item %v is %v, not archived or deleted", itemID, item.Status)
+		return types.ErrItemStatusTransition
+	}
+	status := models.ItemStatusWanted
+	if item.ReservedQuantity >= item.Quantity {
+		status = models.ItemStatusReserved
+	}
+	return s.transitionItem(item, status, logger)
+}
+
+// findReservedItem returns the item if the user holds a reservation for it.
+func (s *Service) findReservedItem(userID, itemID int, logger *logrus.Entry) (*models.ItemModel, error) {
+	item, err := s.findItem(itemID, false, logger)
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := s.items.FindReservation(item.ID, userID)
+	if err != nil {
+		logger.Errorf("Failed DB Query to find reservation: %s", err.Error())
+		return nil, types.ErrEditItem
+	}
+	if reservation == nil {
+		logger.Errorf("User %v has no reservation for item %v", userID, itemID)
+		return nil, types.ErrItemUnauthorized
+	}
+	return item, nil
+}
+
+// AddItem adds an item to the supplied wishlist, owned by the wishlist owner.
+// Items without a wishlist can only be added by admins.
+func (s *Service) AddItem(name, url string, rank int, listID *int, details types.ItemDetails, userID int, isAdmin bool, logger *logrus.Entry) error {
+	ownerID, err := s.newItemOwner(listID, userID, isAdmin, logger)
+	if err != nil {
+		logger.Errorf("Failed to Add Item: %v", name)
+		return err
+	}
+	newItem := newItemModel(name, url, rank, listID, ownerID, details)
+	if err := s.items.AddItem(&newItem); err != nil {
 		logger.Errorf("Failed to Add Item: %v, Error: %v", name, err.Error())
 		return types.ErrAddItemErr
 	}
 	return nil
 }
 
-func DeleteItem(itemID int, logger *logrus.Entry) error {
-	item, err := models.FindOneItem(map[string]interface{}{"id": itemID})
+// ImportItems adds all of the items to the list in a single transaction and
+// returns how many were added. With dryRun nothing is added, but the caller
+// is still checked against the list. Tags the owner doesn't have yet are
+// created first, and are kept even if adding the items then fails.
+func (s *Service) ImportItems(listID *int, items []types.ItemImport, userID int, isAdmin, dryRun bool, logger *logrus.Entry) (int, error) {
+	ownerID, err := s.newItemOwner(listID, userID, isAdmin, logger)
 	if err != nil {
-		logger.Errorf("DeleteItem: Failed DB Query to find item: %s", err.Error())
-		return types.ErrDeleteItem
+		logger.Error("Failed to Import Items")
+		return 0, err
 	}
-	if item == nil {
-		logger.Error("DeleteItem: Item Doesn't Exist")
-		return types.ErrDeleteItem
+	newItems := []models.ItemModel{}
+	for _, item := range items {
+		newItems = append(newItems, newItemModel(item.Name, item.URL, item.Rank, listID, ownerID, item.Details))
 	}
+	if dryRun {
+		return len(newItems), nil
+	}
+	tags, err := s.importTags(ownerID, items, logger)
+	if err != nil {
+		logger.Error("Failed to Import Items")
+		return 0, err
+	}
+	for i, item := range items {
+		for _, name := range item.Tags {
+			newItems[i].Tags = append(newItems[i].Tags, *tags[name])
+		}
+	}
+	if err := s.items.AddItems(newItems); err != nil {
+		logger.Errorf("Failed to Import Items, Error: %v", err.Error())
+		return 0, types.ErrAddItemErr
+	}
+	return len(newItems), nil
+}
+
+// importTags finds the owners tags named by the imported items, creating the
+// ones they don't have yet.
+func (s *Service) importTags(ownerID int, items []types.ItemImport, logger *logrus.Entry) (map[string]*models.TagModel, error) {
+	tags := map[string]*models.TagModel{}
+	for _, item := range items {
+		for _, name := range item.Tags {
+			if tags[name] != nil {
+				continue
+			}
+			tag, err := s.tags.FindTagByName(ownerID, name)
+			if err == nil && tag == nil {
+				tag = &models.TagModel{OwnerID: ownerID, Name: name}
+				err = s.tags.CreateTag(tag)
+			}
+			if err != nil {
+				logger.Errorf("Failed to find or add the imported tag %v: %s", name, err.Error())
+				return nil, types.ErrAddItemErr
+			}
+			tags[name] = tag
+		}
+	}
+	return tags, nil
+}
+
+// newItemOwner returns who owns an item added to the list, which is the lists
+// owner. Only admins can add items without a list.
+func (s *Service) newItemOwner(listID *int, userID int, isAdmin bool, logger *logrus.Entry) (int, error) {
+	if listID == nil {
+		if !isAdmin {
+			logger.Error("Only admins can add items without a wishlist")
+			return 0, types.ErrItemUnauthorized
+		}
+		return userID, nil
+	}
+	list, err := s.findManagedWishlist(*listID, userID, isAdmin, logger)
+	if err != nil {
+		logger.Errorf("Failed to find wishlist %v to add items to", *listID)
+		return 0, err
+	}
+	return list.OwnerID, nil
+}
 
-	if deleteErr := models.DeleteItem(item); deleteErr != nil {
+func newItemModel(name, url string, rank int, listID *int, ownerID int, details types.ItemDetails) models.ItemModel {
+	if details.Quantity < 1 {
+		details.Quantity = 1
+	}
+	return models.ItemModel{Name: name, URL: url, Rank: rank, OwnerID: &ownerID, WishlistID: listID,
+		Price: details.Price, Currency: strings.ToUpper(details.Currency), Quantity: details.Quantity,
+		Notes: details.Notes, ImageURL: details.ImageURL, Size: details.Size, Color: details.Color}
+}
+
+func (s *Service) DeleteItem(itemID, userID int, isAdmin bool, logger *logrus.Entry) error {
+	item, err := s.findManagedItem(itemID, userID, isAdmin, logger)
+	if err != nil {
+		logger.Error("DeleteItem: Failed to find item")
+		return err
+	}
+
+	if deleteErr := s.items.DeleteItem(item); deleteErr != nil {
 		logger.Errorf("Failed to Delete Item: %v", item.Name)
 		return types.ErrDeleteItem
 	}
 
+	s.notifyReserversOfRemoval(item, logger)
 	return nil
 }
 
-func itemDBModelToResponse(items *[]models.ItemModel) *[]types.Items {
+// notifyReserversOfRemoval emails everyone holding a reservation for the item
+// to let them know it was removed. Failures are only logged, the item is
+// still flagged as removed in their reserved items.
+func (s *Service) notifyReserversOfRemoval(item *models.ItemModel, logger *logrus.Entry) {
+	reservations, err := s.items.GetItemReservations(item.ID)
+	if err != nil {
+		logger.Errorf("Failed DB Query to find reservations of removed item: %s", err.Error())
+		return
+	}
+	mailer := sgmail.GetMailer()
+	for _, reservation := range *reservations {
+		user, err := s.users.FindUser(reservation.UserID)
+		if err != nil || user == nil {
+			logger.Errorf("Failed to find reserver %v of removed item %v", reservation.UserID, item.ID)
+			continue
+		}
+		message := fmt.Sprintf("Hi %s,\n\n"+
+			"An item you reserved has been removed from its wishlist by the owner:\n\n"+
+			"%s\n\n"+
+			"It will stay in your reserved items, marked as removed, until you unreserve it.",
+			user.FirstName, item.Name)
+		if err := mailer.SendMail(user.EMail, "Jatgam Wishlist Reserved Item Removed", message, logger); err != nil {
+			logger.Errorf("Failed to email reserver %v of removed item: %s", reservation.UserID, err.Error())
+		}
+	}
+}
+
+// findItem returns the item, including soft deleted items if withDeleted is
+// set.
+func (s *Service) findItem(itemID int, withDeleted bool, logger *logrus.Entry) (*models.ItemModel, error) {
+	item, err := s.items.FindItem(itemID, withDeleted)
+	if err != nil {
+		logger.Errorf("Failed DB Query to find item: %s", err.Error())
+		return nil, types.ErrEditItem
+	}
+	if item == nil {
+		logger.Debugf("Item Doesn't Exist: %v", itemID)
+		return nil, types.ErrItemNotFound
+	}
+	return item, nil
+}
+
+// findManagedItem returns the item if the user owns it or is an admin. Items
+// without an owner can only be managed by admins.
+func (s *Service) findManagedItem(itemID, userID int, isAdmin bool, logger *logrus.Entry) (*models.ItemModel, error) {
+	item, err := s.findItem(itemID, false, logger)
+	if err != nil {
+		return nil, err
+	}
+	if !canManageItem(item, userID, isAdmin) {
+		logger.Debugf("User %v does not own item %v", userID, itemID)
+		return nil, types.ErrItemUnauthorized
+	}
+	return item, nil
+}
+
+func canManageItem(item *models.ItemModel, userID int, isAdmin bool) bool {
+	return isAdmin || (item.OwnerID != nil && *item.OwnerID == userID)
+}
+
+// reservationsHidden reports if surprise mode on the items wishlist hides its
+// reservations from the user. A list that can't be found counts as hidden, so
+// a failure can't spoil the surprise.
+func (s *Service) reservationsHidden(item *models.ItemModel, userID int, logger *logrus.Entry) bool {
+	if item.WishlistID == nil {
+		return false
+	}
+	list, err := s.findWishlist(*item.WishlistID, logger)
+	return err != nil || surpriseActive(list, userID)
+}
+
+// withUsersReservations fills in how much of each item the user has reserved.
+func (s *Service) withUsersReservations(items *[]types.Items, userID int, logger *logrus.Entry) (*[]types.Items, error) {
+	reservations, err := s.items.GetUserReservations(userID)
+	if err != nil {
+		logger.Errorf("Failed DB Query to get reservations: %s", err.Error())
+		return nil, types.ErrGetReservedItemsDB
+	}
+	reserved := map[int]int{}
+	for _, reservation := range *reservations {
+		reserved[reservation.ItemID] = reservation.Quantity
+	}
+	for i := range *items {
+		(*items)[i].MyReservedQuantity = reserved[(*items)[i].ID]
+	}
+	return items, nil
+}
+
+// itemDBModelToResponse converts items for a response. Items on a wishlist in
+// hiddenLists are shown as unreserved, to keep the surprise for the list owner.
+func itemDBModelToResponse(items *[]models.ItemModel, hiddenLists map[int]bool) *[]types.Items {
 	// We want to initialize now, because json.Marshal will return null instead
 	// of [] if we have no items.
 	resp := []types.Items{}
 	for _, item := range *items {
-		resp = append(resp, types.Items{Name: item.Name,
-			Rank: item.Rank, Url: item.URL, ID: item.ID, Reserved: item.Reserved,
-			CreatedAt: item.CreatedAt, UpdatedAt: item.UpdatedAt})
+		if item.WishlistID != nil && hiddenLists[*item.WishlistID] {
+			item.Reserved = false
+			item.ReservedQuantity = 0
+			if item.Status == models.ItemStatusReserved || item.Status == models.ItemStatusPurchased {
+				item.Status = models.ItemStatusWanted
+			}
+		}
+		tags := []string{}
+		for _, tag := range item.Tags {
+			tags = append(tags, tag.Name)
+		}
+		resp = append(resp, types.Items{OwnerID: item.OwnerID, Name: item.Name,
+			Rank: item.Rank, Url: item.URL, ID: item.ID, Reserved: item.Reserved, Status: item.Status, Removed: item.DeletedAt != nil,
+			Remaining: item.Quantity - item.ReservedQuantity,
+			ListID:    item.WishlistID, Price: item.Price, Currency: item.Currency, Quantity: item.Quantity,
+			Notes: item.Notes, ImageURL: item.ImageURL, Size: item.Size, Color: item.Color, Tags: tags, CreatedAt: item.CreatedAt, UpdatedAt: item.UpdatedAt})
 	}
 	return &resp
 }
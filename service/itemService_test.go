@@ -0,0 +1,183 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jatgam/wishlist-api/models"
+	"github.com/jatgam/wishlist-api/types"
+)
+
+func pageNames(page *types.ItemPage) string {
+	names := []string{}
+	for _, item := range *page.Items {
+		names = append(names, item.Name)
+	}
+	return fmt.Sprint(names)
+}
+
+func TestReserveItem(t *testing.T) {
+	svc, store := newTestService(Settings{})
+	owner := createTestUser(t, store, "owner")
+	alice := createTestUser(t, store, "alice")
+	bob := createTestUser(t, store, "bob")
+	list := createTestList(t, store, &models.WishlistModel{OwnerID: owner.ID})
+	private := createTestList(t, store, &models.WishlistModel{OwnerID: owner.ID, Visibility: models.WishlistVisibilityPrivate})
+	item := createTestItem(t, store, &models.ItemModel{Name: "Socks", OwnerID: &owner.ID, WishlistID: &list.ID, Quantity: 2})
+	hidden := createTestItem(t, store, &models.ItemModel{Name: "Ring", OwnerID: &owner.ID, WishlistID: &private.ID})
+
+	checkErr(t, "Reserving your own item", svc.ReserveItem(owner.ID, item.ID, 1, false, testLogger), types.ErrReserveOwnItem)
+	checkErr(t, "Reserving more than the quantity", svc.ReserveItem(alice.ID, item.ID, 3, false, testLogger), types.ErrItemNotEnoughRemaining)
+	checkErr(t, "Reserving on a private list", svc.ReserveItem(alice.ID, hidden.ID, 1, false, testLogger), types.ErrItemNotFound)
+	checkErr(t, "Reserving", svc.ReserveItem(alice.ID, item.ID, 1, false, testLogger), nil)
+	checkErr(t, "Reserving the rest", svc.ReserveItem(bob.ID, item.ID, 1, false, testLogger), nil)
+	checkErr(t, "Reserving a fully reserved item", svc.ReserveItem(alice.ID, item.ID, 1, false, testLogger), types.ErrItemNotEnoughRemaining)
+
+	found, _ := store.FindItem(item.ID, false)
+	if !found.Reserved || found.ReservedQuantity != 2 || found.Status != models.ItemStatusReserved {
+		t.Errorf("Item has reserved %v, reservedqty %v and status %v", found.Reserved, found.ReservedQuantity, found.Status)
+	}
+	page, err := svc.GetReservedItems(alice.ID, types.ItemQuery{}, testLogger)
+	if err != nil || pageNames(page) != "[Socks]" || (*page.Items)[0].MyReservedQuantity != 1 {
+		t.Errorf("Alice's reserved items are %v, %v, want Socks with 1 reserved by her", page, err)
+	}
+
+	checkErr(t, "Unreserving without a reservation", svc.UnReserveItem(owner.ID, item.ID, testLogger), types.ErrItemUnauthorized)
+	checkErr(t, "Unreserving", svc.UnReserveItem(bob.ID, item.ID, testLogger), nil)
+	found, _ = store.FindItem(item.ID, false)
+	if found.Reserved || found.ReservedQuantity != 1 || found.Status != models.ItemStatusWanted {
+		t.Errorf("Released item has reserved %v, reservedqty %v and status %v", found.Reserved, found.ReservedQuantity, found.Status)
+	}
+}
+
+func TestItemStatusTransitions(t *testing.T) {
+	svc, store := newTestService(Settings{})
+	owner := createTestUser(t, store, "owner")
+	alice := createTestUser(t, store, "alice")
+	list := createTestList(t, store, &models.WishlistModel{OwnerID: owner.ID})
+	item := createTestItem(t, store, &models.ItemModel{Name: "Socks", OwnerID: &owner.ID, WishlistID: &list.ID})
+
+	checkErr(t, "Purchasing without a reservation", svc.PurchaseItem(alice.ID, item.ID, testLogger), types.ErrItemUnauthorized)
+	checkErr(t, "Reserving", svc.ReserveItem(alice.ID, item.ID, 1, false, testLogger), nil)
+	reserved, _ := store.FindItem(item.ID, false)
+	checkErr(t, "Purchasing", svc.PurchaseItem(alice.ID, item.ID, testLogger), nil)
+	if found, _ := store.FindItem(item.ID, false); !found.UpdatedAt.Equal(reserved.UpdatedAt) {
+		t.Error("Purchasing changed the items updatedAt, hinting at it on surprise wishlists")
+	}
+	checkErr(t, "Unreserving a purchased item", svc.UnReserveItem(alice.ID, item.ID, testLogger), types.ErrItemStatusTransition)
+	checkErr(t, "Unpurchasing", svc.UnPurchaseItem(alice.ID, item.ID, testLogger), nil)
+	checkErr(t, "Unpurchasing a reserved item", svc.UnPurchaseItem(alice.ID, item.ID, testLogger), types.ErrItemStatusTransition)
+	checkErr(t, "Receiving someone elses item", svc.ReceiveItem(item.ID, alice.ID, false, testLogger), types.ErrItemUnauthorized)
+	checkErr(t, "Receiving", svc.ReceiveItem(item.ID, owner.ID, false, testLogger), nil)
+	checkErr(t, "Restoring a received item", svc.RestoreItem(item.ID, owner.ID, false, testLogger), types.ErrItemStatusTransition)
+	checkErr(t, "Archiving", svc.ArchiveItem(item.ID, owner.ID, false, testLogger), nil)
+	checkErr(t, "Restoring", svc.RestoreItem(item.ID, owner.ID, false, testLogger), nil)
+
+	// The reservation still covers the quantity, so it comes back reserved.
+	if found, _ := store.FindItem(item.ID, false); found.Status != models.ItemStatusReserved {
+		t.Errorf("Restored item is %v, want %v", found.Status, models.ItemStatusReserved)
+	}
+
+	checkErr(t, "Deleting", svc.DeleteItem(item.ID, owner.ID, false, testLogger), nil)
+	page, err := svc.GetReservedItems(alice.ID, types.ItemQuery{}, testLogger)
+	if err != nil || len(*page.Items) != 1 || !(*page.Items)[0].Removed {
+		t.Errorf("Alice's reserved items are %v, %v, want the removed item", page, err)
+	}
+	checkErr(t, "Restoring a deleted item", svc.RestoreItem(item.ID, owner.ID, false, testLogger), nil)
+}
+
+func TestRestoreDeletedItem(t *testing.T) {
+	svc, store := newTestService(Settings{})
+	owner := createTestUser(t, store, "owner")
+	alice := createTestUser(t, store, "alice")
+	list := createTestList(t, store, &models.WishlistModel{OwnerID: owner.ID})
+	item := createTestItem(t, store, &models.ItemModel{Name: "Socks", OwnerID: &owner.ID, WishlistID: &list.ID})
+
+	checkErr(t, "Reserving", svc.ReserveItem(alice.ID, item.ID, 1, false, testLogger), nil)
+	checkErr(t, "Deleting", svc.DeleteItem(item.ID, owner.ID, false, testLogger), nil)
+	checkErr(t, "Unreserving the deleted item", svc.UnReserveItem(alice.ID, item.ID, testLogger), nil)
+	checkErr(t, "Restoring", svc.RestoreItem(item.ID, owner.ID, false, testLogger), nil)
+	found, _ := store.FindItem(item.ID, false)
+	if found.Reserved || found.ReservedQuantity != 0 || found.Status != models.ItemStatusWanted {
+		t.Errorf("Restored item has reserved %v, reservedqty %v and status %v", found.Reserved, found.ReservedQuantity, found.Status)
+	}
+
+	checkErr(t, "Deleting the wishlist", svc.DeleteWishlist(list.ID, owner.ID, false, testLogger), nil)
+	checkErr(t, "Restoring an item of a deleted wishlist", svc.RestoreItem(item.ID, owner.ID, false, testLogger),
+		types.ErrWishlistNotFound)
+}
+
+func TestSurpriseMode(t *testing.T) {
+	svc, store := newTestService(Settings{})
+	owner := createTestUser(t, store, "owner")
+	alice := createTestUser(t, store, "alice")
+	list := createTestList(t, store, &models.WishlistModel{OwnerID: owner.ID, SurpriseMode: true})
+	plain := createTestList(t, store, &models.WishlistModel{OwnerID: owner.ID, Title: "Plain"})
+	lamp := createTestItem(t, store, &models.ItemModel{Name: "Lamp", OwnerID: &owner.ID, WishlistID: &list.ID, Rank: 1, Quantity: 2})
+	createTestItem(t, store, &models.ItemModel{Name: "Mug", OwnerID: &owner.ID, WishlistID: &list.ID, Rank: 2})
+	createTestItem(t, store, &models.ItemModel{Name: "Vase", OwnerID: &owner.ID, WishlistID: &list.ID, Rank: 3,
+		Status: models.ItemStatusReceived})
+	socks := createTestItem(t, store, &models.ItemModel{Name: "Socks", OwnerID: &owner.ID, WishlistID: &plain.ID, Quantity: 2})
+	checkErr(t, "Reserving", svc.ReserveItem(alice.ID, lamp.ID, 2, false, testLogger), nil)
+	checkErr(t, "Reserving", svc.ReserveItem(alice.ID, socks.ID, 2, false, testLogger), nil)
+
+	page, err := svc.GetWishlistWantedItems(list.ID, alice.ID, false, types.ItemQuery{}, testLogger)
+	if err != nil || pageNames(page) != "[Mug]" {
+		t.Errorf("Alice's wanted items are %v, %v, want [Mug]", pageNames(page), err)
+	}
+
+	// The owner still sees the reserved lamp as wanted, but not the received
+	// vase.
+	page, err = svc.GetWishlistWantedItems(list.ID, owner.ID, false, types.ItemQuery{}, testLogger)
+	if err != nil || pageNames(page) != "[Lamp Mug]" || page.Total != 2 {
+		t.Fatalf("The owners wanted items are %v of %v, %v, want [Lamp Mug] of 2", pageNames(page), page.Total, err)
+	}
+	for _, item := range *page.Items {
+		if item.Reserved || item.Status != models.ItemStatusWanted || item.Remaining != item.Quantity {
+			t.Errorf("The owner sees %s with reserved %v, status %v and %v of %v remaining",
+				item.Name, item.Reserved, item.Status, item.Remaining, item.Quantity)
+		}
+	}
+	for status, want := range map[string]string{models.ItemStatusWanted: "[Lamp Mug]", models.ItemStatusReserved: "[]"} {
+		query := types.ItemQuery{Status: status}
+		if page, _ := svc.GetWishlistWantedItems(list.ID, owner.ID, false, query, testLogger); pageNames(page) != want {
+			t.Errorf("The owners wanted items with status %v are %v, want %v", status, pageNames(page), want)
+		}
+		if page, _ := svc.GetWishlistAllItems(list.ID, owner.ID, false, query, testLogger); pageNames(page) != want {
+			t.Errorf("The owners items with status %v are %v, want %v", status, pageNames(page), want)
+		}
+	}
+	if page, _ := svc.GetWishlistAllItems(list.ID, owner.ID, false, types.ItemQuery{}, testLogger); pageNames(page) != "[Lamp Mug Vase]" {
+		t.Errorf("The owners items are %v, want [Lamp Mug Vase]", pageNames(page))
+	}
+
+	// Lowering the quantity below what is reserved is refused, unless that
+	// would give the surprise away.
+	one := 1
+	checkErr(t, "Lowering the quantity of a reserved item", svc.EditItem(socks.ID, owner.ID, false, types.ItemEdit{Quantity: &one}, testLogger),
+		types.ErrItemQuantityReserved)
+	checkErr(t, "Lowering the quantity of a surprise item", svc.EditItem(lamp.ID, owner.ID, false, types.ItemEdit{Quantity: &one}, testLogger), nil)
+	if found, _ := store.FindItem(lamp.ID, false); found.Quantity != 2 || found.Status != models.ItemStatusReserved {
+		t.Errorf("Lamp has quantity %v and status %v, want 2 and %v", found.Quantity, found.Status, models.ItemStatusReserved)
+	}
+}
+
+func TestWishlistItemsPaging(t *testing.T) {
+	svc, store := newTestService(Settings{})
+	owner := createTestUser(t, store, "owner")
+	list := createTestList(t, store, &models.WishlistModel{OwnerID: owner.ID})
+	for i, name := range []string{"Lamp", "Mug", "Vase"} {
+		createTestItem(t, store, &models.ItemModel{Name: name, OwnerID: &owner.ID, WishlistID: &list.ID, Rank: i + 1})
+	}
+
+	page, err := svc.GetWishlistWantedItems(list.ID, 0, false, types.ItemQuery{Limit: 2}, testLogger)
+	if err != nil || pageNames(page) != "[Lamp Mug]" || page.Total != 3 || page.NextCursor == "" {
+		t.Fatalf("The first page is %v of %v with cursor %q, %v", pageNames(page), page.Total, page.NextCursor, err)
+	}
+	page, err = svc.GetWishlistWantedItems(list.ID, 0, false, types.ItemQuery{Limit: 2, Cursor: page.NextCursor}, testLogger)
+	if err != nil || pageNames(page) != "[Vase]" || page.NextCursor != "" {
+		t.Errorf("The second page is %v with cursor %q, %v, want [Vase] and no cursor", pageNames(page), page.NextCursor, err)
+	}
+	_, err = svc.GetWishlistWantedItems(list.ID, 0, false, types.ItemQuery{Cursor: "!"}, testLogger)
+	checkErr(t, "Listing with a bad cursor", err, types.ErrItemInvalidCursor)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"time"
+
+	"github.com/jatgam/wishlist-api/models"
+)
+
+// Service implements the business rules of the API. It only reaches the
+// database through its repositories, so the rules can be run against any
+// implementation of them.
+type Service struct {
+	users     models.UserRepository
+	items     models.ItemRepository
+	wishlists models.WishlistRepository
+	tags      models.TagRepository
+	invites   models.InviteRepository
+	settings  Settings
+}
+
+// The registration modes. Registering while invite only takes a code from an
+// admin.
+const (
+	RegistrationOpen       = "open"
+	RegistrationInviteOnly = "invite-only"
+	RegistrationClosed     = "closed"
+)
+
+// Settings configure the business rules.
+type Settings struct {
+	// RegistrationMode is one of the registration modes, open when empty.
+	RegistrationMode string
+	// Secret signs the email verification links.
+	Secret []byte
+	// VerifyWindow is how long a new account has to verify its email before
+	// it is deleted.
+	VerifyWindow time.Duration
+	// ResendCooldown is how long to wait between verification emails.
+	ResendCooldown time.Duration
+}
+
+// NewService creates a Service using the supplied repositories.
+func NewService(users models.UserRepository, items models.ItemRepository, wishlists models.WishlistRepository, tags models.TagRepository,
+	invites models.InviteRepository, settings Settings) *Service {
+	return &Service{users: users, items: items, wishlists: wishlists, tags: tags, invites: invites, settings: settings}
+}
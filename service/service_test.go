@@ -0,0 +1,80 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jatgam/wishlist-api/models"
+	"github.com/jatgam/wishlist-api/models/memory"
+	"github.com/jatgam/wishlist-api/service/sgmail"
+)
+
+var testLogger *logrus.Entry
+
+func TestMain(m *testing.M) {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	testLogger = logrus.NewEntry(logger)
+	sgmail.SetupMail("", "Jatgam Wishlist", "wishlist@example.com", true)
+	os.Exit(m.Run())
+}
+
+// newTestService creates a Service on an empty memory store.
+func newTestService(settings Settings) (*Service, *memory.Store) {
+	if settings.Secret == nil {
+		settings.Secret = []byte("secret")
+	}
+	if settings.VerifyWindow == 0 {
+		settings.VerifyWindow = time.Hour
+	}
+	if settings.ResendCooldown == 0 {
+		settings.ResendCooldown = time.Minute
+	}
+	store := memory.NewStore()
+	return NewService(store, store, store, store, store, settings), store
+}
+
+func createTestUser(t *testing.T, store *memory.Store, username string) *models.UserModel {
+	user := &models.UserModel{Username: username, EMail: username + "@example.com", FirstName: username,
+		LastName: "Test", UserLevel: 1, EMailVerified: true}
+	if err := user.SetPassword("Password123!x"); err != nil {
+		t.Fatalf("Hashing the password failed: %s", err.Error())
+	}
+	if err := store.CreateUser(user); err != nil {
+		t.Fatalf("Creating user %s failed: %s", username, err.Error())
+	}
+	return user
+}
+
+func createTestList(t *testing.T, store *memory.Store, list *models.WishlistModel) *models.WishlistModel {
+	if list.Title == "" {
+		list.Title = "Birthday"
+	}
+	if list.Visibility == "" {
+		list.Visibility = models.WishlistVisibilityPublic
+	}
+	if err := store.CreateWishlist(list); err != nil {
+		t.Fatalf("Creating the wishlist failed: %s", err.Error())
+	}
+	return list
+}
+
+func createTestItem(t *testing.T, store *memory.Store, item *models.ItemModel) *models.ItemModel {
+	item.URL = "https://example.com/" + item.Name
+	if err := store.AddItem(item); err != nil {
+		t.Fatalf("Adding item %s failed: %s", item.Name, err.Error())
+	}
+	return item
+}
+
+// checkErr fails the test if err isn't the wanted error.
+func checkErr(t *testing.T, action string, err, want error) {
+	t.Helper()
+	if err != want {
+		t.Errorf("%s returned %v, want %v", action, err, want)
+	}
+}
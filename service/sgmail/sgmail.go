@@ -1,6 +1,10 @@
 package sgmail
 
 import (
+	"fmt"
+	"net/http"
+
+	"github.com/sendgrid/rest"
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
 	"github.com/sirupsen/logrus"
@@ -12,12 +16,13 @@ type SGConfig struct {
 	FromAddress string
 	Enabled     bool
 	Debug       bool
+	apiKey      string
 }
 
 var sgMailer *SGConfig
 
 func SetupMail(apikey, fromName, fromAddress string, debug bool) {
-	newMailer := &SGConfig{FromName: fromName, FromAddress: fromAddress, Debug: debug}
+	newMailer := &SGConfig{FromName: fromName, FromAddress: fromAddress, Debug: debug, apiKey: apikey}
 	if apikey != "" {
 		newMailer.Client = sendgrid.NewSendClient(apikey)
 		newMailer.Enabled = true
@@ -60,3 +65,21 @@ func (sg *SGConfig) SendMail(toAddress, subject, message string, logger *logrus.
 	logger.WithFields(fields).Info("Email Sent")
 	return nil
 }
+
+// Check asks SendGrid whether the API key can be used, for the health checks.
+// A disabled mailer has nothing to check.
+func (sg *SGConfig) Check() error {
+	if !sg.Enabled {
+		return nil
+	}
+	request := sendgrid.GetRequest(sg.apiKey, "/v3/scopes", "")
+	request.Method = rest.Get
+	response, err := sendgrid.API(request)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("sendgrid returned status %d", response.StatusCode)
+	}
+	return nil
+}
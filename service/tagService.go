@@ -0,0 +1,145 @@
+package service
+
+import (
+	"github.com/sirupsen/logrus"
+
+	"github.com/jatgam/wishlist-api/models"
+	"github.com/jatgam/wishlist-api/types"
+)
+
+// GetTags returns the tags the user has created.
+func (s *Service) GetTags(userID int, logger *logrus.Entry) (*[]types.Tag, error) {
+	tags, err := s.tags.GetTags(userID)
+	if err != nil {
+		logger.Errorf("GetTags: Failed DB Query: %s", err.Error())
+		return nil, types.ErrGetTagsDB
+	}
+	logger.Infof("Got %v tags.", len(*tags))
+	return tagDBModelToResponse(tags), nil
+}
+
+func (s *Service) AddTag(ownerID int, name string, logger *logrus.Entry) error {
+	if err := s.checkTagNameFree(ownerID, name, logger); err != nil {
+		return err
+	}
+	if err := s.tags.CreateTag(&models.TagModel{OwnerID: ownerID, Name: name}); err != nil {
+		logger.Errorf("Failed to Add Tag: %v, Error: %v", name, err.Error())
+		return types.ErrAddTag
+	}
+	return nil
+}
+
+func (s *Service) RenameTag(tagID, userID int, isAdmin bool, name string, logger *logrus.Entry) error {
+	tag, err := s.findManagedTag(tagID, userID, isAdmin, logger)
+	if err != nil {
+		return err
+	}
+	if tag.Name == name {
+		return nil
+	}
+	if err := s.checkTagNameFree(tag.OwnerID, name, logger); err != nil {
+		return err
+	}
+	if err := s.tags.UpdateTag(tag, map[string]interface{}{"name": name}); err != nil {
+		logger.Errorf("RenameTag: Failed DB Query to update tag: %s", err.Error())
+		return types.ErrEditTag
+	}
+	return nil
+}
+
+func (s *Service) DeleteTag(tagID, userID int, isAdmin bool, logger *logrus.Entry) error {
+	tag, err := s.findManagedTag(tagID, userID, isAdmin, logger)
+	if err != nil {
+		return err
+	}
+	if err := s.tags.DeleteTag(tag); err != nil {
+		logger.Errorf("Failed to Delete Tag: %s", err.Error())
+		return types.ErrDeleteTag
+	}
+	return nil
+}
+
+// TagItem adds one of the item owners tags to the item.
+func (s *Service) TagItem(itemID, tagID, userID int, isAdmin bool, logger *logrus.Entry) error {
+	item, tag, err := s.findTaggableItem(itemID, tagID, userID, isAdmin, logger)
+	if err != nil {
+		return err
+	}
+	if err := s.tags.TagItem(item, tag); err != nil {
+		logger.Errorf("TagItem: Failed DB Query to tag item: %s", err.Error())
+		return types.ErrEditItem
+	}
+	return nil
+}
+
+func (s *Service) UntagItem(itemID, tagID, userID int, isAdmin bool, logger *logrus.Entry) error {
+	item, tag, err := s.findTaggableItem(itemID, tagID, userID, isAdmin, logger)
+	if err != nil {
+		return err
+	}
+	if err := s.tags.UntagItem(item, tag); err != nil {
+		logger.Errorf("UntagItem: Failed DB Query to untag item: %s", err.Error())
+		return types.ErrEditItem
+	}
+	return nil
+}
+
+// findTaggableItem returns the item and tag if the user manages both and the
+// tag belongs to the items owner.
+func (s *Service) findTaggableItem(itemID, tagID, userID int, isAdmin bool, logger *logrus.Entry) (*models.ItemModel, *models.TagModel, error) {
+	item, err := s.findManagedItem(itemID, userID, isAdmin, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	tag, err := s.findManagedTag(tagID, userID, isAdmin, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if item.OwnerID != nil && *item.OwnerID != tag.OwnerID {
+		logger.Debugf("Tag %v does not belong to the owner of item %v", tagID, itemID)
+		return nil, nil, types.ErrTagUnauthorized
+	}
+	return item, tag, nil
+}
+
+// findManagedTag returns the tag if the user owns it or is an admin.
+func (s *Service) findManagedTag(tagID, userID int, isAdmin bool, logger *logrus.Entry) (*models.TagModel, error) {
+	tag, err := s.tags.FindTag(tagID)
+	if err != nil {
+		logger.Errorf("Failed DB Query to find tag: %s", err.Error())
+		return nil, types.ErrEditTag
+	}
+	if tag == nil {
+		logger.Debugf("Tag Doesn't Exist: %v", tagID)
+		return nil, types.ErrTagNotFound
+	}
+	if !isAdmin && tag.OwnerID != userID {
+		logger.Debugf("User %v does not own tag %v", userID, tagID)
+		return nil, types.ErrTagUnauthorized
+	}
+	return tag, nil
+}
+
+func (s *Service) checkTagNameFree(ownerID int, name string, logger *logrus.Entry) error {
+	existing, err := s.tags.FindTagByName(ownerID, name)
+	if err != nil {
+		logger.Errorf("Failed DB Query to find tag: %s", err.Error())
+		return types.ErrEditTag
+	}
+	if existing != nil {
+		logger.Debugf("User %v already has a tag named %v", ownerID, name)
+		return types.ErrTagNameTaken
+	}
+	return nil
+}
+
+func tagDBModelToResponse(tags *[]models.TagModel) *[]types.Tag {
+	// We want to initialize now, because json.Marshal will return null instead
+	// of [] if we have no tags.
+	resp := []types.Tag{}
+	for _, tag := range *tags {
+		resp = append(resp, types.Tag{ID: tag.ID, OwnerID: tag.OwnerID, Name: tag.Name,
+			CreatedAt: tag.CreatedAt, UpdatedAt: tag.UpdatedAt})
+	}
+	return &resp
+}
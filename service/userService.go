@@ -1,7 +1,11 @@
 package service
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,10 +17,24 @@ import (
 	"github.com/jatgam/wishlist-api/utils"
 )
 
-func RegisterUser(username, password, email, firstname, lastname string, logger *logrus.Entry) error {
+// RegisterUser creates an account and mails a link to verify its email
+// address. The account can't log in until the address is verified. While
+// registration is invite only, the invite code is used up by registering,
+// and given back if creating the account fails.
+func (s *Service) RegisterUser(username, password, email, firstname, lastname, inviteCode, hostUrl string, logger *logrus.Entry) error {
+	switch s.settings.RegistrationMode {
+	case RegistrationClosed:
+		logger.Debugf("Registration Closed: %s", username)
+		return types.ErrRegistrationClosed
+	case RegistrationInviteOnly:
+		if inviteCode == "" {
+			logger.Debugf("Registration Without Invite: %s", username)
+			return types.ErrInviteRequired
+		}
+	}
 	username = strings.ToLower(username)
 	email = strings.ToLower(email)
-	userTaken, err := usernameTaken(username)
+	userTaken, err := s.usernameTaken(username)
 	if err != nil {
 		logger.Errorf("User Registration Failed: %s", err)
 		return types.ErrUserRegister
@@ -25,7 +43,7 @@ func RegisterUser(username, password, email, firstname, lastname string, logger
 		logger.Debugf("Username Taken: %s", username)
 		return types.ErrUsernameTaken
 	}
-	emTaken, err := emailTaken(email)
+	emTaken, err := s.emailTaken(email)
 	if err != nil {
 		logger.Errorf("User Registration Failed: %s", err)
 		return types.ErrUserRegister
@@ -39,19 +57,173 @@ func RegisterUser(username, password, email, firstname, lastname string, logger
 		logger.Errorf("User Registration Password Hash Failed: %s", err)
 		return types.ErrUserRegister
 	}
+	if s.settings.RegistrationMode == RegistrationInviteOnly {
+		used, err := s.invites.UseInvite(inviteCode, time.Now())
+		if err != nil {
+			logger.Errorf("User Registration Invite Use Failed: %s", err)
+			return types.ErrUserRegister
+		}
+		if !used {
+			logger.Debugf("Invalid Invite: %s", inviteCode)
+			return types.ErrInviteInvalid
+		}
+	}
 	newUser := &models.UserModel{Username: username, PasswordHash: hash,
 		EMail: email, FirstName: firstname, LastName: lastname, UserLevel: 1}
-	err = models.CreateUser(newUser)
+	err = s.users.CreateUser(newUser)
 	if err != nil {
 		logger.Errorf("User Registration DB Insert Failed: %s", err)
+		if s.settings.RegistrationMode == RegistrationInviteOnly {
+			if err := s.invites.ReleaseInvite(inviteCode); err != nil {
+				logger.Errorf("User Registration Invite Release Failed: %s", err)
+			}
+		}
 		return types.ErrUserRegister
 	}
+	if err := s.sendVerifyEMail(newUser, hostUrl, logger); err != nil {
+		return types.ErrVerifyEMailSend
+	}
+	return nil
+}
+
+// ResendVerifyEMail mails the verification link again, at most once per
+// ResendCooldown. Unknown and already verified addresses, and those sent to
+// too recently, succeed without mailing, so the result doesn't tell whether
+// an address has an account.
+func (s *Service) ResendVerifyEMail(email, hostUrl string, logger *logrus.Entry) error {
+	email = strings.ToLower(email)
+	user, err := s.users.FindUserByEMail(email)
+	if err != nil {
+		logger.Errorf("ResendVerifyEMail: Failed DB query: %s", email)
+		return types.ErrVerifyEMailResend
+	}
+	if user == nil {
+		logger.Debugf("ResendVerifyEMail: No user record: %s", email)
+		return nil
+	}
+	if user.EMailVerified {
+		logger.Debugf("ResendVerifyEMail: Already verified: %s", email)
+		return nil
+	}
+	if user.EMailVerifySentAt != nil && time.Since(*user.EMailVerifySentAt) < s.settings.ResendCooldown {
+		logger.Debugf("ResendVerifyEMail: Sent too recently: %s", email)
+		return nil
+	}
+	if err := s.sendVerifyEMail(user, hostUrl, logger); err != nil {
+		return types.ErrVerifyEMailResend
+	}
+	return nil
+}
+
+// VerifyEMail marks the account of a verification link as verified. Using a
+// link again once verified succeeds.
+func (s *Service) VerifyEMail(token string, logger *logrus.Entry) error {
+	userID, expires, ok := parseEMailToken(token)
+	if !ok {
+		logger.Debugf("VerifyEMail: Malformed token: %s", token)
+		return types.ErrVerifyEMail
+	}
+	user, err := s.users.FindUser(userID)
+	if err != nil {
+		logger.Errorf("VerifyEMail: Failed DB query: %s", err.Error())
+		return types.ErrVerifyEMailServerErr
+	}
+	if user == nil {
+		logger.Debugf("VerifyEMail: No user record: %v", userID)
+		return types.ErrVerifyEMail
+	}
+	if !hmac.Equal([]byte(token), []byte(s.emailToken(emailVerifyPurpose, user.ID, user.EMail, expires))) {
+		logger.Debugf("VerifyEMail: Bad signature: %s", token)
+		return types.ErrVerifyEMail
+	}
+	if user.EMailVerified {
+		return nil
+	}
+	if time.Now().Unix() > expires {
+		logger.Debugf("VerifyEMail: Expired token: %s", token)
+		return types.ErrVerifyEMail
+	}
+	if err := s.users.UpdateUser(user, map[string]interface{}{"EMailVerified": true}); err != nil {
+		logger.Errorf("VerifyEMail: Failed to Update User in DB: %s", err.Error())
+		return types.ErrVerifyEMailServerErr
+	}
 	return nil
 }
 
-func PasswordForgot(email, hostUrl string, logger *logrus.Entry) error {
+// DeleteUnverifiedUsers removes the accounts that didn't verify their email
+// within the VerifyWindow, freeing their usernames and addresses.
+func (s *Service) DeleteUnverifiedUsers(logger *logrus.Entry) (int, error) {
+	deleted, err := s.users.DeleteUnverifiedUsers(time.Now().Add(-s.settings.VerifyWindow))
+	if err != nil {
+		logger.Errorf("DeleteUnverifiedUsers: Failed DB query: %s", err.Error())
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// sendVerifyEMail mails the users verification link, which expires when the
+// account would be deleted, and records when it was sent.
+func (s *Service) sendVerifyEMail(user *models.UserModel, hostUrl string, logger *logrus.Entry) error {
+	token := s.emailToken(emailVerifyPurpose, user.ID, user.EMail, user.CreatedAt.Add(s.settings.VerifyWindow).Unix())
+	message := fmt.Sprintf("You are receiving this because you (or someone else) registered an account with this email address.\n\n"+
+		"Please click the following link, or paste into your browser to verify the address:\n\n"+
+		"https://%s/verify_email/%s\n\n"+
+		"Username: %s\n\n"+
+		"If you did not register, please ignore this email and the account will be deleted.",
+		hostUrl, token, user.Username)
+
+	mailer := sgmail.GetMailer()
+	if err := mailer.SendMail(user.EMail, "Jatgam Wishlist Verify Your EMail", message, logger); err != nil {
+		logger.Errorf("sendVerifyEMail: Error Sending Verification Email: %s", err.Error())
+		return err
+	}
+	now := time.Now()
+	if err := s.users.UpdateUser(user, map[string]interface{}{"EMailVerifySentAt": &now}); err != nil {
+		logger.Errorf("sendVerifyEMail: Failed to Update User in DB: %s", err.Error())
+		return err
+	}
+	return nil
+}
+
+// The purposes of the email tokens, so a token mailed for one can't be used
+// for the other.
+const (
+	emailVerifyPurpose  = "verify_email"
+	emailConfirmPurpose = "confirm_email"
+)
+
+// emailToken signs the user, the email address it is mailed to and the
+// expiry, so a link stops working if the address changes. Nothing is stored
+// for it, the address is already on the user, as the current one when
+// verifying or the pending one when confirming a change. The token is
+// userID.expires.signature.
+func (s *Service) emailToken(purpose string, userID int, email string, expires int64) string {
+	mac := hmac.New(sha256.New, s.settings.Secret)
+	fmt.Fprintf(mac, "%s:%d:%d:%s", purpose, userID, expires, email)
+	return fmt.Sprintf("%d.%d.%s", userID, expires, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// parseEMailToken reads the user and expiry of a token, without checking
+// its signature.
+func parseEMailToken(token string) (int, int64, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, 0, false
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return userID, expires, true
+}
+
+func (s *Service) PasswordForgot(email, hostUrl string, logger *logrus.Entry) error {
 	email = strings.ToLower(email)
-	user, err := models.FindOneUser(&models.UserModel{EMail: email}, models.UserPassResetScope)
+	user, err := s.users.FindUserByEMail(email)
 	if err != nil {
 		logger.Errorf("PasswordForgot: Failed DB query: %s", email)
 		return types.ErrPasswordForgot
@@ -68,8 +240,8 @@ func PasswordForgot(email, hostUrl string, logger *logrus.Entry) error {
 	}
 
 	expirationTime := time.Now().Add(time.Hour)
-	updates := models.UserModel{PasswordResetToken: &token, PasswordResetExpires: &expirationTime}
-	err = models.UpdateUser(user, updates)
+	updates := map[string]interface{}{"PasswordResetToken": &token, "PasswordResetExpires": &expirationTime}
+	err = s.users.UpdateUser(user, updates)
 	if err != nil {
 		logger.Errorf("PasswordForgot: Failed to Update User in DB: %s", err.Error())
 		return types.ErrPasswordForgot
@@ -91,8 +263,8 @@ func PasswordForgot(email, hostUrl string, logger *logrus.Entry) error {
 	return nil
 }
 
-func PasswordResetTokenValidate(token string, logger *logrus.Entry) (bool, error) {
-	user, err := models.FindOneUser(&models.UserModel{PasswordResetToken: &token}, models.UserPassResetScope)
+func (s *Service) PasswordResetTokenValidate(token string, logger *logrus.Entry) (bool, error) {
+	user, err := s.users.FindUserByResetToken(token)
 	if err != nil {
 		logger.Errorf("PasswordResetTokenValidate: Failed DB query: %s", token)
 		return false, types.ErrPasswordResetValidateServerErr
@@ -112,15 +284,15 @@ func PasswordResetTokenValidate(token string, logger *logrus.Entry) (bool, error
 	return false, nil
 }
 
-func PasswordReset(email, password, token string, logger *logrus.Entry) error {
+func (s *Service) PasswordReset(email, password, token string, logger *logrus.Entry) error {
 	email = strings.ToLower(email)
-	tokenValid, _ := PasswordResetTokenValidate(token, logger)
+	tokenValid, _ := s.PasswordResetTokenValidate(token, logger)
 	if !tokenValid {
 		logger.Debugf("Password Reset for %s:%s had invalid token.", email, token)
 		return types.ErrPasswordResetValidate
 	}
 
-	user, err := models.FindOneUser(&models.UserModel{EMail: email}, models.UserAuthScope)
+	user, err := s.users.FindUserByEMail(email)
 	if err != nil {
 		logger.Errorf("PasswordReset: Failed DB query: %s", email)
 		return types.ErrPasswordResetServerErr
@@ -140,9 +312,11 @@ func PasswordReset(email, password, token string, logger *logrus.Entry) error {
 		logger.Errorf("PasswordReset Password Hash Failed: %s", err)
 		return types.ErrPasswordResetServerErr
 	}
-	updates := map[string]interface{}{"PasswordHash": hash, "PasswordReset": false, "PasswordResetToken": nil, "PasswordResetExpires": nil}
+	// Bumping the version logs out whoever may have had the old password.
+	updates := map[string]interface{}{"PasswordHash": hash, "PasswordReset": false, "PasswordResetToken": nil, "PasswordResetExpires": nil,
+		"TokenVersion": user.TokenVersion + 1}
 	// updates := models.UserModel{PasswordHash: hash, PasswordReset: false, PasswordResetToken: nil, PasswordResetExpires: nil}
-	err = models.UpdateUserWithMap(user, updates)
+	err = s.users.UpdateUser(user, updates)
 	if err != nil {
 		logger.Errorf("PasswordReset: Failed to Update User in DB: %s", err.Error())
 		return types.ErrPasswordResetServerErr
@@ -151,8 +325,180 @@ func PasswordReset(email, password, token string, logger *logrus.Entry) error {
 	return nil
 }
 
-func usernameTaken(username string) (bool, error) {
-	user, err := models.FindOneUser(&models.UserModel{Username: username})
+// GetProfile returns the users own account.
+func (s *Service) GetProfile(userID int, logger *logrus.Entry) (*types.User, error) {
+	user, err := s.findUser(userID, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &types.User{ID: user.ID, Username: user.Username, EMail: user.EMail, PendingEMail: user.PendingEMail,
+		FirstName: user.FirstName, LastName: user.LastName, UserLevel: user.UserLevel,
+		CreatedAt: user.CreatedAt, UpdatedAt: user.UpdatedAt}, nil
+}
+
+// EditProfile changes the users names straight away. A new email address is
+// held as pending, and only replaces the current one once confirmed from the
+// link mailed to it. Returns true if a confirmation was sent.
+func (s *Service) EditProfile(userID int, edit types.ProfileEdit, hostUrl string, logger *logrus.Entry) (bool, error) {
+	user, err := s.findUser(userID, logger)
+	if err != nil {
+		return false, err
+	}
+	updates := map[string]interface{}{}
+	if edit.FirstName != nil {
+		updates["firstname"] = *edit.FirstName
+	}
+	if edit.LastName != nil {
+		updates["lastname"] = *edit.LastName
+	}
+	var email, token string
+	if edit.EMail != nil && strings.ToLower(*edit.EMail) != user.EMail {
+		email = strings.ToLower(*edit.EMail)
+		emTaken, err := s.emailTaken(email)
+		if err != nil {
+			logger.Errorf("EditProfile: Failed DB query: %s", err.Error())
+			return false, types.ErrEditProfile
+		}
+		if emTaken {
+			logger.Debugf("EMail Taken: %s", email)
+			return false, types.ErrEmailTaken
+		}
+		token = s.emailToken(emailConfirmPurpose, user.ID, email, time.Now().Add(24*time.Hour).Unix())
+		updates["PendingEMail"] = &email
+	}
+	if len(updates) == 0 {
+		logger.Debug("EditProfile: Nothing to update")
+		return false, nil
+	}
+	if err := s.users.UpdateUser(user, updates); err != nil {
+		logger.Errorf("EditProfile: Failed to Update User in DB: %s", err.Error())
+		return false, types.ErrEditProfile
+	}
+	if token == "" {
+		return false, nil
+	}
+
+	message := fmt.Sprintf("You are receiving this because the email address of your account was changed to this one.\n\n"+
+		"Please click the following link, or paste into your browser to confirm it:\n\n"+
+		"https://%s/email_confirm/%s\n\n"+
+		"Username: %s\n\n"+
+		"If you did not request this, please ignore this email and the address will not be used.",
+		hostUrl, token, user.Username)
+
+	mailer := sgmail.GetMailer()
+	if err := mailer.SendMail(email, "Jatgam Wishlist EMail Confirmation", message, logger); err != nil {
+		logger.Errorf("EditProfile: Error Sending Confirmation Email: %s", err.Error())
+		return false, types.ErrEditProfile
+	}
+	return true, nil
+}
+
+// ConfirmEMail replaces the users email address with the pending one the
+// token was mailed to. Changing the pending address again stops earlier links
+// working.
+func (s *Service) ConfirmEMail(token string, logger *logrus.Entry) error {
+	userID, expires, ok := parseEMailToken(token)
+	if !ok {
+		logger.Debugf("ConfirmEMail: Malformed token: %s", token)
+		return types.ErrEMailConfirm
+	}
+	user, err := s.users.FindUser(userID)
+	if err != nil {
+		logger.Errorf("ConfirmEMail: Failed DB query: %s", err.Error())
+		return types.ErrEMailConfirmServerErr
+	}
+	if user == nil || user.PendingEMail == nil {
+		logger.Debugf("ConfirmEMail: No pending change for user %v", userID)
+		return types.ErrEMailConfirm
+	}
+	if !hmac.Equal([]byte(token), []byte(s.emailToken(emailConfirmPurpose, user.ID, *user.PendingEMail, expires))) {
+		logger.Debugf("ConfirmEMail: Bad signature: %s", token)
+		return types.ErrEMailConfirm
+	}
+	if time.Now().Unix() > expires {
+		logger.Debugf("ConfirmEMail: Expired token: %s", token)
+		return types.ErrEMailConfirm
+	}
+	// The address may have been registered since the change was requested.
+	emTaken, err := s.emailTaken(*user.PendingEMail)
+	if err != nil {
+		logger.Errorf("ConfirmEMail: Failed DB query: %s", err.Error())
+		return types.ErrEMailConfirmServerErr
+	}
+	if emTaken {
+		logger.Debugf("EMail Taken: %s", *user.PendingEMail)
+		return types.ErrEmailTaken
+	}
+	updates := map[string]interface{}{"EMail": *user.PendingEMail, "PendingEMail": nil}
+	if err := s.users.UpdateUser(user, updates); err != nil {
+		logger.Errorf("ConfirmEMail: Failed to Update User in DB: %s", err.Error())
+		return types.ErrEMailConfirmServerErr
+	}
+	return nil
+}
+
+// ChangePassword sets a new password once the current one is confirmed, and
+// clears any required password change. Every token issued before the change
+// stops working, including the one used to make it.
+func (s *Service) ChangePassword(userID int, currentPassword, newPassword string, logger *logrus.Entry) error {
+	user, err := s.users.FindUserForAuth(userID)
+	if err != nil {
+		logger.Errorf("ChangePassword: Failed DB query: %s", err.Error())
+		return types.ErrGetUserDB
+	}
+	if user == nil {
+		logger.Debugf("ChangePassword: No user found with ID: %v", userID)
+		return types.ErrUserNotFound
+	}
+	if !user.ValidatePassword(currentPassword) {
+		logger.Debugf("ChangePassword: Wrong current password for user: %v", userID)
+		return types.ErrCurrentPasswordWrong
+	}
+
+	hash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		logger.Errorf("ChangePassword Password Hash Failed: %s", err)
+		return types.ErrChangePassword
+	}
+	updates := map[string]interface{}{"PasswordHash": hash, "PasswordReset": false, "TokenVersion": user.TokenVersion + 1}
+	if err := s.users.UpdateUser(user, updates); err != nil {
+		logger.Errorf("ChangePassword: Failed to Update User in DB: %s", err.Error())
+		return types.ErrChangePassword
+	}
+	return nil
+}
+
+// RequirePasswordChange flags the user so they can do nothing but change their
+// password, for after an account is compromised. Their tokens are revoked so
+// the flag applies from their next login.
+func (s *Service) RequirePasswordChange(userID int, logger *logrus.Entry) error {
+	user, err := s.findUser(userID, logger)
+	if err != nil {
+		return err
+	}
+	updates := map[string]interface{}{"PasswordReset": true, "TokenVersion": user.TokenVersion + 1}
+	if err := s.users.UpdateUser(user, updates); err != nil {
+		logger.Errorf("RequirePasswordChange: Failed to Update User in DB: %s", err.Error())
+		return types.ErrRequirePasswordChange
+	}
+	return nil
+}
+
+func (s *Service) findUser(userID int, logger *logrus.Entry) (*models.UserModel, error) {
+	user, err := s.users.FindUser(userID)
+	if err != nil {
+		logger.Errorf("Failed DB Query to find user: %s", err.Error())
+		return nil, types.ErrGetUserDB
+	}
+	if user == nil {
+		logger.Debugf("No user found with ID: %v", userID)
+		return nil, types.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *Service) usernameTaken(username string) (bool, error) {
+	user, err := s.users.FindUserByUsername(username)
 	if err != nil {
 		return true, err
 	}
@@ -162,8 +508,8 @@ func usernameTaken(username string) (bool, error) {
 	return false, nil
 }
 
-func emailTaken(email string) (bool, error) {
-	user, err := models.FindOneUser(&models.UserModel{EMail: email})
+func (s *Service) emailTaken(email string) (bool, error) {
+	user, err := s.users.FindUserByEMail(email)
 	if err != nil {
 		return true, err
 	}
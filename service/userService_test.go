@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jatgam/wishlist-api/models"
+	"github.com/jatgam/wishlist-api/models/memory"
+	"github.com/jatgam/wishlist-api/types"
+)
+
+// failingUsers is a memory store whose inserts fail, like an insert losing a
+// race for a unique username.
+type failingUsers struct {
+	*memory.Store
+}
+
+func (failingUsers) CreateUser(newUser *models.UserModel) error {
+	return errors.New("insert failed")
+}
+
+func TestRegisterUserInvite(t *testing.T) {
+	svc, store := newTestService(Settings{RegistrationMode: RegistrationInviteOnly})
+	if err := store.CreateInvite(&models.InviteModel{Code: "once", CreatorID: 1, MaxUses: 1}); err != nil {
+		t.Fatalf("Creating the invite failed: %s", err.Error())
+	}
+	register := func(svc *Service, username string) error {
+		return svc.RegisterUser(username, "Password123!x", username+"@example.com", username, "Test", "once", "example.com", testLogger)
+	}
+
+	failing := NewService(failingUsers{store}, store, store, store, store, svc.settings)
+	checkErr(t, "Registering with a failing insert", register(failing, "alice"), types.ErrUserRegister)
+	checkErr(t, "Registering with the invite", register(svc, "alice"), nil)
+	checkErr(t, "Registering with a used up invite", register(svc, "bob"), types.ErrInviteInvalid)
+}
+
+func TestPasswordReset(t *testing.T) {
+	svc, store := newTestService(Settings{})
+	user := createTestUser(t, store, "alice")
+
+	checkErr(t, "Requesting a reset", svc.PasswordForgot(user.EMail, "example.com", testLogger), nil)
+	found, _ := store.FindUserByEMail(user.EMail)
+	if found.PasswordResetToken == nil {
+		t.Fatal("Requesting a reset didn't store a token")
+	}
+	checkErr(t, "Resetting", svc.PasswordReset(user.EMail, "NewPassword456!x", *found.PasswordResetToken, testLogger), nil)
+
+	found, _ = store.FindUserByEMail(user.EMail)
+	if !found.ValidatePassword("NewPassword456!x") || found.PasswordResetToken != nil {
+		t.Error("Resetting didn't change the password and clear the token")
+	}
+	if found.TokenVersion != user.TokenVersion+1 {
+		t.Errorf("Resetting left the token version at %v, want %v", found.TokenVersion, user.TokenVersion+1)
+	}
+}
+
+func TestConfirmEMail(t *testing.T) {
+	svc, store := newTestService(Settings{})
+	user := createTestUser(t, store, "alice")
+	change := func(email string) string {
+		if sent, err := svc.EditProfile(user.ID, types.ProfileEdit{EMail: &email}, "example.com", testLogger); err != nil || !sent {
+			t.Fatalf("Changing the email to %s returned %v, %v", email, sent, err)
+		}
+		return svc.emailToken(emailConfirmPurpose, user.ID, email, time.Now().Add(time.Hour).Unix())
+	}
+
+	first := change("first@example.com")
+	second := change("second@example.com")
+	verify := svc.emailToken(emailVerifyPurpose, user.ID, "second@example.com", time.Now().Add(time.Hour).Unix())
+	checkErr(t, "Confirming with a verification token", svc.ConfirmEMail(verify, testLogger), types.ErrEMailConfirm)
+	checkErr(t, "Confirming a replaced change", svc.ConfirmEMail(first, testLogger), types.ErrEMailConfirm)
+	checkErr(t, "Confirming", svc.ConfirmEMail(second, testLogger), nil)
+	checkErr(t, "Confirming again", svc.ConfirmEMail(second, testLogger), types.ErrEMailConfirm)
+
+	found, _ := store.FindUser(user.ID)
+	if found.EMail != "second@example.com" || found.PendingEMail != nil {
+		t.Errorf("Confirmed user has email %v and pending %v", found.EMail, found.PendingEMail)
+	}
+}
+
+func TestResendVerifyEMail(t *testing.T) {
+	svc, store := newTestService(Settings{ResendCooldown: time.Hour, VerifyWindow: time.Hour})
+	if err := svc.RegisterUser("alice", "Password123!x", "alice@example.com", "alice", "Test", "", "example.com", testLogger); err != nil {
+		t.Fatalf("Registering failed: %s", err.Error())
+	}
+	bob := createTestUser(t, store, "bob")
+	sentAt := func(email string) *time.Time {
+		user, _ := store.FindUserByEMail(email)
+		return user.EMailVerifySentAt
+	}
+	registered := sentAt("alice@example.com")
+
+	// Every address answers the same, only an unverified one out of its
+	// cooldown is mailed.
+	checkErr(t, "Resending to an unknown address", svc.ResendVerifyEMail("nobody@example.com", "example.com", testLogger), nil)
+	checkErr(t, "Resending to a verified address", svc.ResendVerifyEMail(bob.EMail, "example.com", testLogger), nil)
+	checkErr(t, "Resending within the cooldown", svc.ResendVerifyEMail("alice@example.com", "example.com", testLogger), nil)
+	if sentAt(bob.EMail) != nil || !sentAt("alice@example.com").Equal(*registered) {
+		t.Error("Resending mailed a verified address or one within the cooldown")
+	}
+	svc.settings.ResendCooldown = 0
+	checkErr(t, "Resending", svc.ResendVerifyEMail("alice@example.com", "example.com", testLogger), nil)
+	if sentAt("alice@example.com").Equal(*registered) {
+		t.Error("Resending after the cooldown didn't mail the address")
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/jatgam/wishlist-api/models"
+	"github.com/jatgam/wishlist-api/types"
+)
+
+// GetWishlists returns every wishlist the viewer is allowed to see. A viewerID
+// of 0 is an anonymous viewer.
+func (s *Service) GetWishlists(viewerID int, isAdmin bool, logger *logrus.Entry) (*[]types.Wishlist, error) {
+	lists, err := s.wishlists.GetWishlists()
+	if err != nil {
+		logger.Errorf("GetWishlists: Failed DB Query: %s", err.Error())
+		return nil, types.ErrGetWishlistsDB
+	}
+	visible := []models.WishlistModel{}
+	if lists != nil {
+		for _, list := range *lists {
+			if canViewWishlist(&list, viewerID, isAdmin) {
+				visible = append(visible, list)
+			}
+		}
+	}
+	logger.Infof("Got %v wishlists.", len(visible))
+	return wishlistDBModelToResponse(&visible), nil
+}
+
+func (s *Service) GetWishlist(listID, viewerID int, isAdmin bool, logger *logrus.Entry) (*types.Wishlist, error) {
+	list, err := s.findViewableWishlist(listID, viewerID, isAdmin, logger)
+	if err != nil {
+		return nil, err
+	}
+	resp := (*wishlistDBModelToResponse(&[]models.WishlistModel{*list}))[0]
+	return &resp, nil
+}
+
+func (s *Service) AddWishlist(ownerID int, title string, details types.WishlistDetails, logger *logrus.Entry) error {
+	if details.Visibility == "" {
+		details.Visibility = models.WishlistVisibilityPublic
+	}
+	newList := &models.WishlistModel{OwnerID: ownerID, Title: title, Description: details.Description,
+		Occasion: details.Occasion, OccasionDate: details.OccasionDate, Visibility: details.Visibility,
+		SurpriseMode: details.SurpriseMode}
+	if err := s.wishlists.CreateWishlist(newList); err != nil {
+		logger.Errorf("Failed to Add Wishlist: %v, Error: %v", title, err.Error())
+		return types.ErrAddWishlist
+	}
+	return nil
+}
+
+// EditWishlist updates only the fields that are not nil.
+func (s *Service) EditWishlist(listID, userID int, isAdmin bool, edit types.WishlistEdit, logger *logrus.Entry) error {
+	list, err := s.findManagedWishlist(listID, userID, isAdmin, logger)
+	if err != nil {
+		return err
+	}
+	updates := map[string]interface{}{}
+	if edit.Title != nil {
+		updates["title"] = *edit.Title
+	}
+	if edit.Description != nil {
+		updates["description"] = *edit.Description
+	}
+	if edit.Occasion != nil {
+		updates["occasion"] = *edit.Occasion
+	}
+	if edit.ClearOccasionDate {
+		updates["occasionDate"] = nil
+	} else if edit.OccasionDate != nil {
+		updates["occasionDate"] = *edit.OccasionDate
+	}
+	if edit.Visibility != nil {
+		updates["visibility"] = *edit.Visibility
+	}
+	if edit.SurpriseMode != nil {
+		updates["surprisemode"] = *edit.SurpriseMode
+	}
+	if len(updates) == 0 {
+		logger.Debug("EditWishlist: Nothing to update")
+		return nil
+	}
+	if updateErr := s.wishlists.UpdateWishlist(list, updates); updateErr != nil {
+		logger.Errorf("EditWishlist: Failed DB Query to update wishlist: %s", updateErr.Error())
+		return types.ErrEditWishlist
+	}
+	return nil
+}
+
+// DeleteWishlist deletes the wishlist along with its items. Everyone holding a
+// reservation for one of the items is emailed, the same as when an item is
+// deleted on its own.
+func (s *Service) DeleteWishlist(listID, userID int, isAdmin bool, logger *logrus.Entry) error {
+	list, err := s.findManagedWishlist(listID, userID, isAdmin, logger)
+	if err != nil {
+		return err
+	}
+	items, _, err := s.items.GetWishlistAllItems(listID, models.ItemListOptions{})
+	if err != nil {
+		logger.Errorf("DeleteWishlist: Failed DB Query to get items: %s", err.Error())
+		return types.ErrDeleteWishlist
+	}
+	if deleteErr := s.wishlists.DeleteWishlist(list); deleteErr != nil {
+		logger.Errorf("Failed to Delete Wishlist: %v, Error: %v", list.Title, deleteErr.Error())
+		return types.ErrDeleteWishlist
+	}
+	for i := range *items {
+		if (*items)[i].ReservedQuantity > 0 {
+			s.notifyReserversOfRemoval(&(*items)[i], logger)
+		}
+	}
+	return nil
+}
+
+func (s *Service) findWishlist(listID int, logger *logrus.Entry) (*models.WishlistModel, error) {
+	list, err := s.wishlists.FindWishlist(listID)
+	if err != nil {
+		logger.Errorf("Failed DB Query to find wishlist: %s", err.Error())
+		return nil, types.ErrGetWishlistsDB
+	}
+	if list == nil {
+		logger.Debugf("Wishlist Doesn't Exist: %v", listID)
+		return nil, types.ErrWishlistNotFound
+	}
+	return list, nil
+}
+
+// findViewableWishlist returns the wishlist if the viewer is allowed to see it.
+// Lists the viewer can't see are reported as not found so their existence
+// isn't leaked.
+func (s *Service) findViewableWishlist(listID, viewerID int, isAdmin bool, logger *logrus.Entry) (*models.WishlistModel, error) {
+	list, err := s.findWishlist(listID, logger)
+	if err != nil {
+		return nil, err
+	}
+	if !canViewWishlist(list, viewerID, isAdmin) {
+		logger.Debugf("Wishlist %v not visible to user %v", listID, viewerID)
+		return nil, types.ErrWishlistNotFound
+	}
+	return list, nil
+}
+
+// findManagedWishlist returns the wishlist if the user is the owner or an admin.
+func (s *Service) findManagedWishlist(listID, userID int, isAdmin bool, logger *logrus.Entry) (*models.WishlistModel, error) {
+	list, err := s.findViewableWishlist(listID, userID, isAdmin, logger)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && list.OwnerID != userID {
+		logger.Debugf("User %v does not own wishlist %v", userID, listID)
+		return nil, types.ErrWishlistUnauthorized
+	}
+	return list, nil
+}
+
+func canViewWishlist(list *models.WishlistModel, viewerID int, isAdmin bool) bool {
+	if isAdmin || (viewerID != 0 && list.OwnerID == viewerID) {
+		return true
+	}
+	switch list.Visibility {
+	case models.WishlistVisibilityPublic:
+		return true
+	case models.WishlistVisibilityRegistered:
+		return viewerID != 0
+	default:
+		return false
+	}
+}
+
+// surpriseActive reports if the reservation state of the lists items should be
+// hidden from the viewer. Only the owner is kept in the dark, and only until
+// the occasion date passes.
+func surpriseActive(list *models.WishlistModel, viewerID int) bool {
+	if !list.SurpriseMode || viewerID == 0 || list.OwnerID != viewerID {
+		return false
+	}
+	return list.OccasionDate == nil || time.Now().Before(*list.OccasionDate)
+}
+
+// hiddenReservationLists returns the IDs of the viewers own wishlists that are
+// currently in surprise mode.
+func (s *Service) hiddenReservationLists(viewerID int, logger *logrus.Entry) (map[int]bool, error) {
+	hidden := map[int]bool{}
+	if viewerID == 0 {
+		return hidden, nil
+	}
+	lists, err := s.wishlists.GetSurpriseWishlists(viewerID)
+	if err != nil {
+		logger.Errorf("Failed DB Query to find surprise wishlists: %s", err.Error())
+		return nil, types.ErrGetWishlistsDB
+	}
+	for _, list := range *lists {
+		if surpriseActive(&list, viewerID) {
+			hidden[list.ID] = true
+		}
+	}
+	return hidden, nil
+}
+
+// ExportWishlist returns the wishlist and its items as the viewer may see them.
+// The owner gets every item but never the reservation details, so an export
+// can't spoil anything. Other viewers get the items still wanted, along with
+// how many remain.
+func (s *Service) ExportWishlist(listID, viewerID int, isAdmin bool, logger *logrus.Entry) (*types.WishlistExport, error) {
+	list, err := s.findViewableWishlist(listID, viewerID, isAdmin, logger)
+	if err != nil {
+		return nil, err
+	}
+	isOwner := viewerID != 0 && list.OwnerID == viewerID
+	var items *[]models.ItemModel
+	if isOwner || isAdmin {
+		items, _, err = s.items.GetWishlistAllItems(listID, models.ItemListOptions{})
+	} else {
+		items, _, err = s.items.GetWishlistWantedItems(listID, models.ItemListOptions{})
+	}
+	if err != nil {
+		logger.Errorf("ExportWishlist: Failed DB Query: %s", err.Error())
+		return nil, types.ErrGetAllItemsDB
+	}
+	logger.Infof("Exporting %v items of wishlist %v", len(*items), listID)
+	return &types.WishlistExport{Wishlist: (*wishlistDBModelToResponse(&[]models.WishlistModel{*list}))[0],
+		Items: *itemDBModelToResponse(items, map[int]bool{listID: isOwner}), ShowReservations: !isOwner}, nil
+}
+
+func wishlistDBModelToResponse(lists *[]models.WishlistModel) *[]types.Wishlist {
+	resp := []types.Wishlist{}
+	for _, list := range *lists {
+		resp = append(resp, types.Wishlist{ID: list.ID, OwnerID: list.OwnerID,
+			Title: list.Title, Description: list.Description, Occasion: list.Occasion,
+			OccasionDate: list.OccasionDate, Visibility: list.Visibility, SurpriseMode: list.SurpriseMode, CreatedAt: list.CreatedAt, UpdatedAt: list.UpdatedAt})
+	}
+	return &resp
+}
@@ -13,13 +13,57 @@ var (
 	ErrPasswordResetValidateServerErr error = errors.New("Failed to validate a password reset token: Server Error")
 	ErrPasswordReset                  error = errors.New("Failed to complete the password reset")
 	ErrPasswordResetServerErr         error = errors.New("Failed to complete the password reset: Server Error")
+	ErrUserNotFound                   error = errors.New("User not found")
+	ErrGetUserDB                      error = errors.New("Failed to Get User from DB")
+	ErrEditProfile                    error = errors.New("Failed to update the profile")
+	ErrEMailConfirm                   error = errors.New("Failed to confirm the email address: the link is invalid or expired")
+	ErrEMailConfirmServerErr          error = errors.New("Failed to confirm the email address: Server Error")
+	ErrCurrentPasswordWrong           error = errors.New("The current password is incorrect")
+	ErrChangePassword                 error = errors.New("Failed to change the password")
+	ErrRequirePasswordChange          error = errors.New("Failed to require a password change")
+	ErrVerifyEMail                    error = errors.New("Failed to verify the email address: the link is invalid or expired")
+	ErrVerifyEMailServerErr           error = errors.New("Failed to verify the email address: Server Error")
+	ErrVerifyEMailSend                error = errors.New("Registered, but failed to send the verification email, try resending it")
+	ErrVerifyEMailResend              error = errors.New("Failed to resend the verification email")
+	ErrRegistrationClosed             error = errors.New("Registration is closed")
+	ErrInviteRequired                 error = errors.New("An invite code is required to register")
+	ErrInviteInvalid                  error = errors.New("The invite code is invalid, used up or expired")
 
-	ErrGetWantedItemsDB   error = errors.New("Failed to Get Wanted Items from DB")
-	ErrGetAllItemsDB      error = errors.New("Failed to Get All Items from DB")
-	ErrGetReservedItemsDB error = errors.New("Failed to Get Reserved Items from DB")
-	ErrAddItemErr         error = errors.New("Failed to Add Item")
-	ErrEditItem           error = errors.New("Failed to edit the item")
-	ErrDeleteItem         error = errors.New("Failed to delete the item")
+	ErrGetWantedItemsDB       error = errors.New("Failed to Get Wanted Items from DB")
+	ErrGetAllItemsDB          error = errors.New("Failed to Get All Items from DB")
+	ErrGetReservedItemsDB     error = errors.New("Failed to Get Reserved Items from DB")
+	ErrAddItemErr             error = errors.New("Failed to Add Item")
+	ErrEditItem               error = errors.New("Failed to edit the item")
+	ErrDeleteItem             error = errors.New("Failed to delete the item")
+	ErrItemNotFound           error = errors.New("Item not found")
+	ErrItemUnauthorized       error = errors.New("Not authorized to modify the item")
+	ErrReserveOwnItem         error = errors.New("Can't reserve your own item")
+	ErrItemNotEnoughRemaining error = errors.New("Not enough of the item remaining to reserve")
+	ErrItemQuantityReserved   error = errors.New("Quantity can't be less than the amount already reserved")
+	ErrItemReserveConflict    error = errors.New("The item was reserved by someone else first")
+	ErrItemNotWanted          error = errors.New("The item is no longer wanted")
+	ErrItemStatusTransition   error = errors.New("The item can't be moved to that status")
+	ErrItemInvalidCursor      error = errors.New("Invalid item listing cursor")
+
+	ErrGetWishlistsDB       error = errors.New("Failed to Get Wishlists from DB")
+	ErrWishlistNotFound     error = errors.New("Wishlist not found")
+	ErrWishlistUnauthorized error = errors.New("Not authorized to access the wishlist")
+	ErrAddWishlist          error = errors.New("Failed to Add Wishlist")
+	ErrEditWishlist         error = errors.New("Failed to edit the wishlist")
+	ErrDeleteWishlist       error = errors.New("Failed to delete the wishlist")
+
+	ErrGetTagsDB       error = errors.New("Failed to Get Tags from DB")
+	ErrTagNotFound     error = errors.New("Tag not found")
+	ErrTagUnauthorized error = errors.New("Not authorized to use the tag")
+	ErrTagNameTaken    error = errors.New("A tag with that name already exists")
+	ErrAddTag          error = errors.New("Failed to Add Tag")
+	ErrEditTag         error = errors.New("Failed to edit the tag")
+	ErrDeleteTag       error = errors.New("Failed to delete the tag")
+
+	ErrGetInvitesDB   error = errors.New("Failed to Get Invites from DB")
+	ErrInviteNotFound error = errors.New("Invite not found")
+	ErrAddInvite      error = errors.New("Failed to Add Invite")
+	ErrDeleteInvite   error = errors.New("Failed to delete the invite")
 
 	ErrDeterminingUserIDFromJWT error = errors.New("Failed to determine UserID from jwt")
 )
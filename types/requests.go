@@ -0,0 +1,77 @@
+package types
+
+import "time"
+
+type (
+	// ProfileEdit holds the account fields to change. Nil fields are left
+	// unchanged.
+	ProfileEdit struct {
+		EMail     *string
+		FirstName *string
+		LastName  *string
+	}
+	// WishlistDetails are the optional fields of a new wishlist.
+	WishlistDetails struct {
+		Description  string
+		Occasion     string
+		OccasionDate *time.Time
+		Visibility   string
+		SurpriseMode bool
+	}
+	// WishlistEdit holds the wishlist fields to change. Nil fields are left
+	// unchanged. ClearOccasionDate removes the occasion date instead.
+	WishlistEdit struct {
+		Title             *string
+		Description       *string
+		Occasion          *string
+		OccasionDate      *time.Time
+		ClearOccasionDate bool
+		Visibility        *string
+		SurpriseMode      *bool
+	}
+	// ItemDetails are the optional descriptive fields of a new item.
+	ItemDetails struct {
+		Price    *float64
+		Currency string
+		Quantity int
+		Notes    string
+		ImageURL string
+		Size     string
+		Color    string
+	}
+	// ItemImport is one validated row of an item import. Tags are the names
+	// of the owners tags to put on the item.
+	ItemImport struct {
+		Name    string
+		URL     string
+		Rank    int
+		Details ItemDetails
+		Tags    []string
+	}
+	// ItemEdit holds the item fields to change. Nil fields are left unchanged.
+	ItemEdit struct {
+		Name     *string
+		URL      *string
+		Rank     *int
+		Price    *float64
+		Currency *string
+		Quantity *int
+		Notes    *string
+		ImageURL *string
+		Size     *string
+		Color    *string
+	}
+	// ItemQuery filters, sorts and pages an item listing. Empty fields are
+	// not applied.
+	ItemQuery struct {
+		Status   string
+		MinPrice *float64
+		MaxPrice *float64
+		Name     string
+		Tag      string
+		Sort     string
+		Desc     bool
+		Limit    int
+		Cursor   string
+	}
+)
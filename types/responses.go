@@ -11,18 +11,124 @@ type (
 		Code    int    `json:"code"`
 		Message string `json:"message"`
 	}
+	// User is an account as its owner sees it. PendingEMail is a new
+	// address waiting to be confirmed.
+	User struct {
+		ID           int       `json:"id"`
+		Username     string    `json:"username"`
+		EMail        string    `json:"email"`
+		PendingEMail *string   `json:"pendingEmail"`
+		FirstName    string    `json:"firstName"`
+		LastName     string    `json:"lastName"`
+		UserLevel    uint      `json:"userLevel"`
+		CreatedAt    time.Time `json:"createdAt"`
+		UpdatedAt    time.Time `json:"updateAt"`
+	}
+	GetUserResponse struct {
+		GenericResponse
+		User *User `json:"user"`
+	}
 	Items struct {
+		ID                 int       `json:"id"`
+		OwnerID            *int      `json:"ownerId"`
+		Name               string    `json:"name"`
+		Url                string    `json:"url"`
+		Rank               int       `json:"rank"`
+		Reserved           bool      `json:"reserved"`
+		Status             string    `json:"status"`
+		Removed            bool      `json:"removed"`
+		Remaining          int       `json:"remaining"`
+		MyReservedQuantity int       `json:"myReservedQuantity,omitempty"`
+		ListID             *int      `json:"listId"`
+		Price              *float64  `json:"price"`
+		Currency           string    `json:"currency"`
+		Quantity           int       `json:"quantity"`
+		Notes              string    `json:"notes"`
+		ImageURL           string    `json:"imageUrl"`
+		Size               string    `json:"size"`
+		Color              string    `json:"color"`
+		Tags               []string  `json:"tags"`
+		CreatedAt          time.Time `json:"createdAt"`
+		UpdatedAt          time.Time `json:"updateAt"`
+	}
+	GetItemsResponse struct {
+		GenericResponse
+		Items      *[]Items `json:"items"`
+		Total      *int     `json:"total,omitempty"`
+		NextCursor string   `json:"nextCursor,omitempty"`
+	}
+	// ItemPage is one page of an item listing. NextCursor is empty on the
+	// last page.
+	ItemPage struct {
+		Items      *[]Items
+		Total      int
+		NextCursor string
+	}
+	// WishlistExport is a wishlist and its items as the viewer may see them.
+	// ShowReservations is false when the reservation state was hidden.
+	WishlistExport struct {
+		Wishlist         Wishlist
+		Items            []Items
+		ShowReservations bool
+	}
+	Wishlist struct {
+		ID           int        `json:"id"`
+		OwnerID      int        `json:"ownerId"`
+		Title        string     `json:"title"`
+		Description  string     `json:"description"`
+		Occasion     string     `json:"occasion"`
+		OccasionDate *time.Time `json:"occasionDate"`
+		Visibility   string     `json:"visibility"`
+		SurpriseMode bool       `json:"surpriseMode"`
+		CreatedAt    time.Time  `json:"createdAt"`
+		UpdatedAt    time.Time  `json:"updateAt"`
+	}
+	GetWishlistsResponse struct {
+		GenericResponse
+		Wishlists *[]Wishlist `json:"wishlists"`
+	}
+	GetWishlistResponse struct {
+		GenericResponse
+		Wishlist *Wishlist `json:"wishlist"`
+	}
+	ImportRowError struct {
+		Row   int    `json:"row"`
+		Error string `json:"error"`
+	}
+	ImportItemsResponse struct {
+		GenericResponse
+		DryRun   bool             `json:"dryRun"`
+		Imported int              `json:"imported"`
+		Errors   []ImportRowError `json:"errors"`
+	}
+	Tag struct {
 		ID        int       `json:"id"`
+		OwnerID   int       `json:"ownerId"`
 		Name      string    `json:"name"`
-		Url       string    `json:"url"`
-		Rank      int       `json:"rank"`
-		Reserved  bool      `json:"reserved"`
 		CreatedAt time.Time `json:"createdAt"`
 		UpdatedAt time.Time `json:"updateAt"`
 	}
-	GetItemsResponse struct {
+	GetTagsResponse struct {
 		GenericResponse
-		Items *[]Items `json:"items"`
+		Tags *[]Tag `json:"tags"`
+	}
+	Invite struct {
+		ID        int        `json:"id"`
+		Code      string     `json:"code"`
+		CreatorID int        `json:"creatorId"`
+		MaxUses   int        `json:"maxUses"`
+		Uses      int        `json:"uses"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+		CreatedAt time.Time  `json:"createdAt"`
+		UpdatedAt time.Time  `json:"updateAt"`
+	}
+	GetInvitesResponse struct {
+		GenericResponse
+		Invites *[]Invite `json:"invites"`
+	}
+	GetInviteResponse struct {
+		GenericResponse
+		Invite *Invite `json:"invite"`
 	}
 )
 
@@ -35,8 +141,51 @@ func WriteResponse(c *gin.Context, code int, message string) {
 	c.Abort()
 }
 
-func WriteItemResponse(c *gin.Context, code int, message string, items *[]Items) {
-	resp := GetItemsResponse{GenericResponse{Code: code, Message: message}, items}
+func WriteUserResponse(c *gin.Context, code int, message string, user *User) {
+	resp := GetUserResponse{GenericResponse{Code: code, Message: message}, user}
+	c.JSON(code, resp)
+	c.Abort()
+}
+
+func WriteItemPageResponse(c *gin.Context, code int, message string, page *ItemPage) {
+	resp := GetItemsResponse{GenericResponse: GenericResponse{Code: code, Message: message},
+		Items: page.Items, Total: &page.Total, NextCursor: page.NextCursor}
+	c.JSON(code, resp)
+	c.Abort()
+}
+
+func WriteImportResponse(c *gin.Context, code int, message string, dryRun bool, imported int, rowErrors []ImportRowError) {
+	resp := ImportItemsResponse{GenericResponse{Code: code, Message: message}, dryRun, imported, rowErrors}
+	c.JSON(code, resp)
+	c.Abort()
+}
+
+func WriteWishlistsResponse(c *gin.Context, code int, message string, lists *[]Wishlist) {
+	resp := GetWishlistsResponse{GenericResponse{Code: code, Message: message}, lists}
+	c.JSON(code, resp)
+	c.Abort()
+}
+
+func WriteWishlistResponse(c *gin.Context, code int, message string, list *Wishlist) {
+	resp := GetWishlistResponse{GenericResponse{Code: code, Message: message}, list}
+	c.JSON(code, resp)
+	c.Abort()
+}
+
+func WriteTagsResponse(c *gin.Context, code int, message string, tags *[]Tag) {
+	resp := GetTagsResponse{GenericResponse{Code: code, Message: message}, tags}
+	c.JSON(code, resp)
+	c.Abort()
+}
+
+func WriteInvitesResponse(c *gin.Context, code int, message string, invites *[]Invite) {
+	resp := GetInvitesResponse{GenericResponse{Code: code, Message: message}, invites}
+	c.JSON(code, resp)
+	c.Abort()
+}
+
+func WriteInviteResponse(c *gin.Context, code int, message string, invite *Invite) {
+	resp := GetInviteResponse{GenericResponse{Code: code, Message: message}, invite}
 	c.JSON(code, resp)
 	c.Abort()
 }
@@ -24,3 +24,10 @@ func GetEnvAsBool(key string, defaultValue string) bool {
 	}
 	return value
 }
+
+// GetEnvAsInt will return an environment var if it exists, parsed as an int.
+// Otherwise the default value is parsed. Unlike GetEnvAsBool a value that
+// doesn't parse is an error, as no int makes a safe fallback.
+func GetEnvAsInt(key string, defaultValue string) (int, error) {
+	return strconv.Atoi(GetEnv(key, defaultValue))
+}